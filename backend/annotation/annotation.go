@@ -0,0 +1,219 @@
+// This package handles vector annotations drawn on top of the lab's images.
+// Annotations are stored in a sidecar file next to the image they belong to
+// and can be flattened onto a copy of the image.
+package annotation
+
+import (
+	"encoding/json"
+	"errors"
+	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/fsutil"
+	"flow-poc/backend/filesystem/node"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extension appended to an image's name to get its annotations file
+var sidecarExtension = node.SidecarExtension(node.ANNOTATIONS)
+
+var (
+	ErrNotAnImage       = errors.New("annotations can only be added to images")
+	ErrUnknownShapeKind = errors.New("unknown shape kind")
+	ErrOutOfBounds      = errors.New("coordinates must be between 0 and 1")
+)
+
+type ShapeKind string
+
+const (
+	ARROW ShapeKind = "ARROW"
+	BOX   ShapeKind = "BOX"
+	TEXT  ShapeKind = "TEXT"
+)
+
+var ShapeKinds = []struct {
+	Value  ShapeKind
+	TSName string
+}{
+	{ARROW, "ARROW"},
+	{BOX, "BOX"},
+	{TEXT, "TEXT"},
+}
+
+// A point on the image. Coordinates are relative to the image's size, from 0 to 1,
+// so annotations stay in place whatever the size the image is displayed at
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Shape struct {
+	Kind ShapeKind `json:"kind"`
+	// Start of an arrow, top left corner of a box or position of a text
+	From Point `json:"from"`
+	// Head of an arrow or bottom right corner of a box. Unused by texts
+	To   Point  `json:"to"`
+	Text string `json:"text,omitempty"`
+	// Hexadecimal color: #rgb, #rrggbb or #rrggbbaa
+	Color string `json:"color"`
+	// Width of the lines in pixels of the original image. Defaults to 3
+	StrokeWidth float64 `json:"strokeWidth,omitempty"`
+	// Height of the text in pixels of the original image. Defaults to 13
+	FontSize float64 `json:"fontSize,omitempty"`
+}
+
+type Annotations struct {
+	Shapes []Shape `json:"shapes"`
+}
+
+type AnnotationHandler struct {
+	Cfg *config.AppConfig
+}
+
+func NewAnnotationHandler(cfg *config.AppConfig) *AnnotationHandler {
+	return &AnnotationHandler{
+		Cfg: cfg,
+	}
+}
+
+func (ah *AnnotationHandler) GetLabPath() string {
+	return ah.Cfg.LabPath()
+}
+
+// Given a path to an image starting from the lab root, returns its annotations.
+// An image that was never annotated returns empty annotations
+func (ah *AnnotationHandler) LoadAnnotations(imagePathFromLabRoot string) (Annotations, error) {
+	p, err := ah.imagePath(imagePathFromLabRoot)
+	if err != nil {
+		return Annotations{}, err
+	}
+
+	b, err := os.ReadFile(p + sidecarExtension)
+	if errors.Is(err, os.ErrNotExist) {
+		return Annotations{Shapes: []Shape{}}, nil
+	}
+
+	if err != nil {
+		return Annotations{}, &AnnotationsFileError{p, err}
+	}
+
+	var a Annotations
+	err = json.Unmarshal(b, &a)
+	if err != nil {
+		return Annotations{}, &AnnotationsFileError{p, err}
+	}
+
+	return a, nil
+}
+
+// Validates and writes the annotations of an image in its sidecar file.
+// Saving empty annotations deletes the sidecar file
+func (ah *AnnotationHandler) SaveAnnotations(imagePathFromLabRoot string, a Annotations) error {
+	p, err := ah.imagePath(imagePathFromLabRoot)
+	if err != nil {
+		return err
+	}
+
+	if len(a.Shapes) == 0 {
+		err := os.Remove(p + sidecarExtension)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return &AnnotationsFileError{p, err}
+		}
+
+		return nil
+	}
+
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(a, "", "\t")
+	if err != nil {
+		return &AnnotationsFileError{p, err}
+	}
+
+	err = os.WriteFile(p+sidecarExtension, b, 0644)
+	if err != nil {
+		return &AnnotationsFileError{p, err}
+	}
+
+	return nil
+}
+
+// Draws the annotations of an image onto a copy of it and saves the result as a PNG
+// next to the original image. Returns the path of the new image starting from the lab root
+func (ah *AnnotationHandler) ExportAnnotatedImage(imagePathFromLabRoot string) (string, error) {
+	a, err := ah.LoadAnnotations(imagePathFromLabRoot)
+	if err != nil {
+		return "", err
+	}
+
+	p, err := ah.imagePath(imagePathFromLabRoot)
+	if err != nil {
+		return "", err
+	}
+
+	img, err := decodeImage(p)
+	if err != nil {
+		return "", &ExportError{p, err}
+	}
+
+	flattened, err := Render(img, a)
+	if err != nil {
+		return "", &ExportError{p, err}
+	}
+
+	base := strings.TrimSuffix(p, filepath.Ext(p)) + " annotée"
+	f, err := os.OpenFile(fsutil.NonDuplicatePath(base+".png", false), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", &ExportError{p, err}
+	}
+	defer f.Close()
+
+	err = png.Encode(f, flattened)
+	if err != nil {
+		return "", &ExportError{p, err}
+	}
+
+	rel, err := filepath.Rel(ah.GetLabPath(), f.Name())
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// Checks that every shape is known, placed inside the image and uses a valid color
+func (a Annotations) Validate() error {
+	for i, s := range a.Shapes {
+		switch s.Kind {
+		case ARROW, BOX, TEXT:
+		default:
+			return &InvalidShapeError{i, ErrUnknownShapeKind}
+		}
+
+		if !s.From.inBounds() || (s.Kind != TEXT && !s.To.inBounds()) {
+			return &InvalidShapeError{i, ErrOutOfBounds}
+		}
+
+		if _, err := parseColor(s.Color); err != nil {
+			return &InvalidShapeError{i, err}
+		}
+	}
+
+	return nil
+}
+
+func (p Point) inBounds() bool {
+	return p.X >= 0 && p.X <= 1 && p.Y >= 0 && p.Y <= 1
+}
+
+func (ah *AnnotationHandler) imagePath(pathFromLabRoot string) (string, error) {
+	p := filepath.Join(ah.GetLabPath(), pathFromLabRoot)
+	if node.DetectFileTypeFromPath(p) != node.IMAGE {
+		return "", ErrNotAnImage
+	}
+
+	return p, nil
+}
@@ -0,0 +1,195 @@
+package annotation
+
+import (
+	"errors"
+	"flow-poc/backend/config"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testImageName = "capture.png"
+
+// Creates a temporary lab holding a 100x100 white png image
+func createTempLab(t testing.TB) (string, *AnnotationHandler) {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "testAnnotations")
+	if err != nil {
+		t.Fatalf("an error occured while creating temporary directory: %v", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 100; y++ {
+			img.Set(x, y, color.White)
+		}
+	}
+
+	f, err := os.Create(filepath.Join(dir, testImageName))
+	if err != nil {
+		t.Fatalf("couldn't create test image: %v", err)
+	}
+	defer f.Close()
+
+	err = png.Encode(f, img)
+	if err != nil {
+		t.Fatalf("couldn't encode test image: %v", err)
+	}
+
+	return dir, NewAnnotationHandler(&config.AppConfig{
+		ConfigFile: config.ConfigFile{
+			LabPath: dir,
+		},
+	})
+}
+
+func getTestAnnotations() Annotations {
+	return Annotations{
+		Shapes: []Shape{
+			{Kind: BOX, From: Point{0.1, 0.1}, To: Point{0.5, 0.5}, Color: "#ff0000"},
+			{Kind: ARROW, From: Point{0.9, 0.9}, To: Point{0.6, 0.6}, Color: "#00f", StrokeWidth: 2},
+			{Kind: TEXT, From: Point{0, 0.8}, Text: "5K", Color: "#000000cc", FontSize: 26},
+		},
+	}
+}
+
+func TestSaveAndLoadAnnotations(t *testing.T) {
+	t.Run("save annotations then load them back", func(t *testing.T) {
+		dir, ah := createTempLab(t)
+		defer os.RemoveAll(dir)
+
+		err := ah.SaveAnnotations(testImageName, getTestAnnotations())
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, testImageName+sidecarExtension)); err != nil {
+			t.Fatalf("the sidecar file was not created: %v", err)
+		}
+
+		a, err := ah.LoadAnnotations(testImageName)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(a.Shapes) != 3 {
+			t.Errorf("want 3 shapes, got %d", len(a.Shapes))
+		}
+	})
+
+	t.Run("loading annotations of an image that has none", func(t *testing.T) {
+		dir, ah := createTempLab(t)
+		defer os.RemoveAll(dir)
+
+		a, err := ah.LoadAnnotations(testImageName)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(a.Shapes) != 0 {
+			t.Errorf("want no shape, got %d", len(a.Shapes))
+		}
+	})
+
+	t.Run("shapes outside of the image are refused", func(t *testing.T) {
+		dir, ah := createTempLab(t)
+		defer os.RemoveAll(dir)
+
+		a := Annotations{Shapes: []Shape{{Kind: BOX, From: Point{0, 0}, To: Point{1.5, 1}, Color: "#fff"}}}
+		err := ah.SaveAnnotations(testImageName, a)
+		if !errors.Is(err, ErrOutOfBounds) {
+			t.Errorf("got %v, want %v", err, ErrOutOfBounds)
+		}
+	})
+
+	t.Run("annotations can't be added to something else than an image", func(t *testing.T) {
+		dir, ah := createTempLab(t)
+		defer os.RemoveAll(dir)
+
+		err := ah.SaveAnnotations("graph.json", getTestAnnotations())
+		if !errors.Is(err, ErrNotAnImage) {
+			t.Errorf("got %v, want %v", err, ErrNotAnImage)
+		}
+	})
+}
+
+func TestExportAnnotatedImage(t *testing.T) {
+	dir, ah := createTempLab(t)
+	defer os.RemoveAll(dir)
+
+	err := ah.SaveAnnotations(testImageName, getTestAnnotations())
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	p, err := ah.ExportAnnotatedImage(testImageName)
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	img, err := decodeImage(filepath.Join(dir, p))
+	if err != nil {
+		t.Fatalf("couldn't decode exported image: %v", err)
+	}
+
+	// Top left corner of the box
+	r, g, b, _ := img.At(10, 10).RGBA()
+	if r>>8 != 0xff || g>>8 != 0 || b>>8 != 0 {
+		t.Errorf("the box was not drawn, got color %d %d %d", r>>8, g>>8, b>>8)
+	}
+
+	// Inside of the box stays untouched
+	r, g, b, _ = img.At(30, 30).RGBA()
+	if r>>8 != 0xff || g>>8 != 0xff || b>>8 != 0xff {
+		t.Errorf("the inside of the box should be white, got color %d %d %d", r>>8, g>>8, b>>8)
+	}
+
+	// Middle of the arrow
+	_, _, b, _ = img.At(75, 75).RGBA()
+	if b>>8 != 0xff {
+		t.Errorf("the arrow was not drawn")
+	}
+
+	// Exporting twice never overwrites the previous export
+	p2, err := ah.ExportAnnotatedImage(testImageName)
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if p == p2 {
+		t.Errorf("the second export overwrote the first one")
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		color string
+		want  color.NRGBA
+		err   bool
+	}{
+		{"#fff", color.NRGBA{255, 255, 255, 255}, false},
+		{"#ff000080", color.NRGBA{255, 0, 0, 128}, false},
+		{"red", color.NRGBA{}, true},
+		{"#12345", color.NRGBA{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.color, func(t *testing.T) {
+			got, err := parseColor(tt.color)
+			if tt.err {
+				if !errors.Is(err, ErrInvalidColor) {
+					t.Fatalf("got %v, want %v", err, ErrInvalidColor)
+				}
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
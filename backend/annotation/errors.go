@@ -0,0 +1,42 @@
+package annotation
+
+import "fmt"
+
+type AnnotationsFileError struct {
+	imagePath string
+	err       error
+}
+
+func (a *AnnotationsFileError) Error() string {
+	return fmt.Sprintf("couldn't access annotations of %s: %v", a.imagePath, a.err)
+}
+
+func (a *AnnotationsFileError) Unwrap() error {
+	return a.err
+}
+
+type InvalidShapeError struct {
+	index int
+	err   error
+}
+
+func (i *InvalidShapeError) Error() string {
+	return fmt.Sprintf("shape %d is invalid: %v", i.index, i.err)
+}
+
+func (i *InvalidShapeError) Unwrap() error {
+	return i.err
+}
+
+type ExportError struct {
+	imagePath string
+	err       error
+}
+
+func (e *ExportError) Error() string {
+	return fmt.Sprintf("couldn't export annotated image %s: %v", e.imagePath, e.err)
+}
+
+func (e *ExportError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,217 @@
+package annotation
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	defaultStrokeWidth = 3
+	// Height of a basicfont glyph
+	defaultFontSize = 13
+	// Length of an arrow's head compared to the stroke width
+	arrowHeadRatio = 4
+)
+
+var ErrInvalidColor = errors.New("invalid color")
+
+// Draws every annotation on top of a copy of the given image. The original image is left untouched
+func Render(img image.Image, a Annotations) (*image.RGBA, error) {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+
+	for i, s := range a.Shapes {
+		c, err := parseColor(s.Color)
+		if err != nil {
+			return nil, &InvalidShapeError{i, err}
+		}
+
+		switch s.Kind {
+		case ARROW:
+			drawArrow(dst, s, c)
+		case BOX:
+			drawBox(dst, s, c)
+		case TEXT:
+			drawText(dst, s, c)
+		default:
+			return nil, &InvalidShapeError{i, ErrUnknownShapeKind}
+		}
+	}
+
+	return dst, nil
+}
+
+func decodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// Converts a point relative to the image's size into pixels
+func toPixels(dst *image.RGBA, p Point) (float32, float32) {
+	b := dst.Bounds()
+	return float32(p.X * float64(b.Dx())), float32(p.Y * float64(b.Dy()))
+}
+
+func strokeWidth(s Shape) float64 {
+	if s.StrokeWidth <= 0 {
+		return defaultStrokeWidth
+	}
+
+	return s.StrokeWidth
+}
+
+func drawArrow(dst *image.RGBA, s Shape, c color.Color) {
+	x1, y1 := toPixels(dst, s.From)
+	x2, y2 := toPixels(dst, s.To)
+	w := float32(strokeWidth(s))
+
+	length := float32(math.Hypot(float64(x2-x1), float64(y2-y1)))
+	if length == 0 {
+		return
+	}
+
+	// Unit vector along the arrow and its normal
+	ux, uy := (x2-x1)/length, (y2-y1)/length
+	nx, ny := -uy, ux
+
+	head := min(w*arrowHeadRatio, length)
+	// The shaft stops where the head starts so both don't overlap
+	bx, by := x2-ux*head, y2-uy*head
+
+	r := vector.NewRasterizer(dst.Bounds().Dx(), dst.Bounds().Dy())
+	r.MoveTo(x1+nx*w/2, y1+ny*w/2)
+	r.LineTo(bx+nx*w/2, by+ny*w/2)
+	r.LineTo(bx-nx*w/2, by-ny*w/2)
+	r.LineTo(x1-nx*w/2, y1-ny*w/2)
+	r.ClosePath()
+
+	r.MoveTo(x2, y2)
+	r.LineTo(bx+nx*head/2, by+ny*head/2)
+	r.LineTo(bx-nx*head/2, by-ny*head/2)
+	r.ClosePath()
+
+	r.Draw(dst, dst.Bounds(), image.NewUniform(c), image.Point{})
+}
+
+func drawBox(dst *image.RGBA, s Shape, c color.Color) {
+	x1, y1 := toPixels(dst, s.From)
+	x2, y2 := toPixels(dst, s.To)
+	x1, x2 = min(x1, x2), max(x1, x2)
+	y1, y2 = min(y1, y2), max(y1, y2)
+	w := float32(strokeWidth(s))
+
+	r := vector.NewRasterizer(dst.Bounds().Dx(), dst.Bounds().Dy())
+	// Outer rectangle clockwise then inner rectangle counter clockwise to leave the inside empty
+	r.MoveTo(x1-w/2, y1-w/2)
+	r.LineTo(x2+w/2, y1-w/2)
+	r.LineTo(x2+w/2, y2+w/2)
+	r.LineTo(x1-w/2, y2+w/2)
+	r.ClosePath()
+
+	if x2-x1 > w && y2-y1 > w {
+		r.MoveTo(x1+w/2, y1+w/2)
+		r.LineTo(x1+w/2, y2-w/2)
+		r.LineTo(x2-w/2, y2-w/2)
+		r.LineTo(x2-w/2, y1+w/2)
+		r.ClosePath()
+	}
+
+	r.Draw(dst, dst.Bounds(), image.NewUniform(c), image.Point{})
+}
+
+// Texts are drawn with a bitmap font at its native size then scaled up
+// with a nearest neighbor interpolation to reach the wanted font size
+func drawText(dst *image.RGBA, s Shape, c color.Color) {
+	if s.Text == "" {
+		return
+	}
+
+	face := basicfont.Face7x13
+	lines := strings.Split(s.Text, "\n")
+	width := 0
+	for _, l := range lines {
+		width = max(width, font.MeasureString(face, l).Ceil())
+	}
+
+	lineHeight := face.Metrics().Height.Ceil()
+	txt := image.NewRGBA(image.Rect(0, 0, width, lineHeight*len(lines)))
+	d := font.Drawer{
+		Dst:  txt,
+		Src:  image.NewUniform(c),
+		Face: face,
+	}
+
+	for i, l := range lines {
+		d.Dot = fixed.P(0, i*lineHeight+face.Metrics().Ascent.Ceil())
+		d.DrawString(l)
+	}
+
+	scale := 1.0
+	if s.FontSize > 0 {
+		scale = s.FontSize / defaultFontSize
+	}
+
+	x, y := toPixels(dst, s.From)
+	target := image.Rect(0, 0, int(float64(txt.Bounds().Dx())*scale), int(float64(txt.Bounds().Dy())*scale)).
+		Add(image.Pt(int(x), int(y)))
+
+	xdraw.NearestNeighbor.Scale(dst, target, txt, txt.Bounds(), draw.Over, nil)
+}
+
+// Parses an hexadecimal color written as #rgb, #rrggbb or #rrggbbaa
+func parseColor(s string) (color.NRGBA, error) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if !ok {
+		return color.NRGBA{}, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	if len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+
+	return color.NRGBA{
+		R: uint8(v >> 24),
+		G: uint8(v >> 16),
+		B: uint8(v >> 8),
+		A: uint8(v),
+	}, nil
+}
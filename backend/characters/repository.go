@@ -0,0 +1,95 @@
+package characters
+
+import (
+	"context"
+	"flow-poc/backend/db"
+	"flow-poc/backend/db/repository"
+)
+
+// Characters belong to a game and are deleted along with it
+type CharacterRepository struct {
+	conn *db.Conn
+}
+
+func NewCharacterRepository(conn *db.Conn) *CharacterRepository {
+	return &CharacterRepository{
+		conn,
+	}
+}
+
+func (cr *CharacterRepository) AddCharacter(newCharacter repository.AddCharacterParams) (repository.Character, error) {
+	ctx := context.Background()
+	q, err := cr.conn.Queries()
+	if err != nil {
+		return repository.Character{}, err
+	}
+
+	character, err := q.AddCharacter(ctx, newCharacter)
+	if err != nil {
+		return repository.Character{}, err
+	}
+
+	return character, nil
+}
+
+func (cr *CharacterRepository) GetOneCharacter(id int64) (repository.Character, error) {
+	ctx := context.Background()
+	q, err := cr.conn.Queries()
+	if err != nil {
+		return repository.Character{}, err
+	}
+
+	character, err := q.GetOneCharacter(ctx, id)
+	if err != nil {
+		return repository.Character{}, err
+	}
+
+	return character, nil
+}
+
+// Returns the characters of a game sorted by name
+func (cr *CharacterRepository) ListCharacters(gameId int64) ([]repository.Character, error) {
+	ctx := context.Background()
+	q, err := cr.conn.Queries()
+	if err != nil {
+		return []repository.Character{}, err
+	}
+
+	characters, err := q.ListCharacters(ctx, gameId)
+	if err != nil {
+		return []repository.Character{}, err
+	}
+
+	return characters, err
+}
+
+// Edits everything but the game of the character
+func (cr *CharacterRepository) UpdateCharacter(editedCharacter repository.EditCharacterParams) error {
+	ctx := context.Background()
+	q, err := cr.conn.Queries()
+	if err != nil {
+		return err
+	}
+
+	err = q.EditCharacter(ctx, editedCharacter)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+func (cr *CharacterRepository) DeleteCharacter(id int64) error {
+	ctx := context.Background()
+	q, err := cr.conn.Queries()
+	if err != nil {
+		return err
+	}
+
+	err = q.DeleteCharacter(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
@@ -0,0 +1,99 @@
+package characters
+
+import (
+	"database/sql"
+	"errors"
+	"flow-poc/backend/db/dbtest"
+	"flow-poc/backend/db/repository"
+	"flow-poc/backend/games"
+	"testing"
+)
+
+func TestCharacterRepository(t *testing.T) {
+	conn := dbtest.OpenTemp(t)
+	gr := games.NewGameRepository(conn)
+	cr := NewCharacterRepository(conn)
+
+	game, err := gr.AddGame(repository.AddGameParams{Name: "Guilty Gear Strive"})
+	if err != nil {
+		t.Fatalf("couldn't add game: %v", err)
+	}
+
+	sol, err := cr.AddCharacter(repository.AddCharacterParams{
+		Gameid:           game.ID,
+		Name:             "Sol Badguy",
+		Shortname:        "SO",
+		Archetype:        "Rushdown",
+		Health:           420,
+		Forwardwalkspeed: 3.5,
+		Backwalkspeed:    2.6,
+	})
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	t.Run("characters are listed by game", func(t *testing.T) {
+		_, err := cr.AddCharacter(repository.AddCharacterParams{Gameid: game.ID, Name: "Ky Kiske", Shortname: "KY"})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		list, err := cr.ListCharacters(game.ID)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(list) != 2 || list[0].Name != "Ky Kiske" || list[1] != sol {
+			t.Errorf("wrong characters: %+v", list)
+		}
+	})
+
+	t.Run("a character needs an existing game", func(t *testing.T) {
+		_, err := cr.AddCharacter(repository.AddCharacterParams{Gameid: game.ID + 1, Name: "Ryu"})
+		if err == nil {
+			t.Errorf("adding a character to an unknown game should fail")
+		}
+	})
+
+	t.Run("a character is unique within a game", func(t *testing.T) {
+		_, err := cr.AddCharacter(repository.AddCharacterParams{Gameid: game.ID, Name: "Sol Badguy"})
+		if err == nil {
+			t.Errorf("adding the same character twice should fail")
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		err := cr.UpdateCharacter(repository.EditCharacterParams{
+			ID:               sol.ID,
+			Name:             sol.Name,
+			Shortname:        sol.Shortname,
+			Archetype:        "Power",
+			Health:           sol.Health,
+			Forwardwalkspeed: sol.Forwardwalkspeed,
+			Backwalkspeed:    sol.Backwalkspeed,
+		})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		got, err := cr.GetOneCharacter(sol.ID)
+		if err != nil || got.Archetype != "Power" || got.Gameid != game.ID {
+			t.Errorf("wrong character: %+v, %v", got, err)
+		}
+	})
+
+	t.Run("deleting a game deletes its characters", func(t *testing.T) {
+		if err := gr.DeleteGame(game.ID); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if _, err := cr.GetOneCharacter(sol.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got %v, want %v", err, sql.ErrNoRows)
+		}
+
+		list, err := cr.ListCharacters(game.ID)
+		if err != nil || len(list) != 0 {
+			t.Errorf("the characters of the game should have been deleted: %+v, %v", list, err)
+		}
+	})
+}
@@ -0,0 +1,309 @@
+// This package handles clips and playlists. A clip is a file pointing to a part of a video
+// of the lab, a playlist is a file ordering clips. Neither of them touches the source videos:
+// they are resolved into a source path and a time range that the player seeks to.
+package clip
+
+import (
+	"encoding/json"
+	"errors"
+	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/fsutil"
+	"flow-poc/backend/filesystem/node"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultClipName = "Clip"
+	defaultListName = "Playlist"
+)
+
+var (
+	ErrInvalidRange       = errors.New("the out point must be after the in point and both must be positive")
+	ErrSourceNotAVideo    = errors.New("the source of a clip must be a video")
+	ErrClipSourceNotFound = errors.New("the source video of the clip doesn't exist anymore")
+	ErrNotAClip           = errors.New("the file is not a clip")
+	ErrNotAPlaylist       = errors.New("the file is not a playlist")
+)
+
+type Clip struct {
+	Title string `json:"title"`
+	// Path to the source video starting from the lab root
+	Source string `json:"source"`
+	// In and out points in seconds
+	In  float64 `json:"in"`
+	Out float64 `json:"out"`
+}
+
+type Playlist struct {
+	Title string `json:"title"`
+	// Paths to the clips starting from the lab root, in playing order
+	Clips []string `json:"clips"`
+}
+
+// A clip ready to be played
+type ResolvedClip struct {
+	// Path to the clip file starting from the lab root
+	Path string `json:"path"`
+	Clip
+	Duration float64 `json:"duration"`
+}
+
+type ResolvedPlaylist struct {
+	Title string         `json:"title"`
+	Clips []ResolvedClip `json:"clips"`
+	// Clips that couldn't be resolved, as paths starting from the lab root
+	Broken []string `json:"broken"`
+	// Sum of the durations of every resolved clip
+	Duration float64 `json:"duration"`
+}
+
+type ClipHandler struct {
+	Cfg *config.AppConfig
+}
+
+func NewClipHandler(cfg *config.AppConfig) *ClipHandler {
+	return &ClipHandler{
+		Cfg: cfg,
+	}
+}
+
+func (ch *ClipHandler) GetLabPath() string {
+	return ch.Cfg.LabPath()
+}
+
+// Creates a clip file in the directory given as a path starting from the lab root.
+// The file is named after the clip's title
+func (ch *ClipHandler) CreateClip(dirFromLabRoot string, c Clip) (node.Node, error) {
+	if err := ch.validateClip(c); err != nil {
+		return node.Node{}, err
+	}
+
+	c.Source = filepath.ToSlash(c.Source)
+	return ch.createFile(dirFromLabRoot, fileName(c.Title, defaultClipName), node.CLIP, c)
+}
+
+// Reads a clip file given as a path starting from the lab root
+func (ch *ClipHandler) OpenClip(pathFromLabRoot string) (Clip, error) {
+	if node.DetectFileType(filepath.Ext(pathFromLabRoot)) != node.CLIP {
+		return Clip{}, ErrNotAClip
+	}
+
+	var c Clip
+	err := ch.readFile(pathFromLabRoot, &c)
+	return c, err
+}
+
+// Overwrites an existing clip file
+func (ch *ClipHandler) SaveClip(pathFromLabRoot string, c Clip) error {
+	if node.DetectFileType(filepath.Ext(pathFromLabRoot)) != node.CLIP {
+		return ErrNotAClip
+	}
+
+	if err := ch.validateClip(c); err != nil {
+		return err
+	}
+
+	c.Source = filepath.ToSlash(c.Source)
+	return ch.writeFile(pathFromLabRoot, c)
+}
+
+// Reads a clip and makes sure its source video still exists
+func (ch *ClipHandler) ResolveClip(pathFromLabRoot string) (ResolvedClip, error) {
+	c, err := ch.OpenClip(pathFromLabRoot)
+	if err != nil {
+		return ResolvedClip{}, err
+	}
+
+	if _, err := os.Stat(filepath.Join(ch.GetLabPath(), c.Source)); err != nil {
+		return ResolvedClip{}, &ClipFileError{pathFromLabRoot, ErrClipSourceNotFound}
+	}
+
+	return ResolvedClip{
+		Path:     filepath.ToSlash(pathFromLabRoot),
+		Clip:     c,
+		Duration: c.Out - c.In,
+	}, nil
+}
+
+// Creates a playlist file in the directory given as a path starting from the lab root.
+// The file is named after the playlist's title
+func (ch *ClipHandler) CreatePlaylist(dirFromLabRoot string, p Playlist) (node.Node, error) {
+	if err := validatePlaylist(p); err != nil {
+		return node.Node{}, err
+	}
+
+	p = normalizePlaylist(p)
+	return ch.createFile(dirFromLabRoot, fileName(p.Title, defaultListName), node.PLAYLIST, p)
+}
+
+// Reads a playlist file given as a path starting from the lab root
+func (ch *ClipHandler) OpenPlaylist(pathFromLabRoot string) (Playlist, error) {
+	if node.DetectFileType(filepath.Ext(pathFromLabRoot)) != node.PLAYLIST {
+		return Playlist{}, ErrNotAPlaylist
+	}
+
+	var p Playlist
+	err := ch.readFile(pathFromLabRoot, &p)
+	return normalizePlaylist(p), err
+}
+
+// Overwrites an existing playlist file. Used to rename a playlist or to reorder its clips
+func (ch *ClipHandler) SavePlaylist(pathFromLabRoot string, p Playlist) error {
+	if node.DetectFileType(filepath.Ext(pathFromLabRoot)) != node.PLAYLIST {
+		return ErrNotAPlaylist
+	}
+
+	if err := validatePlaylist(p); err != nil {
+		return err
+	}
+
+	return ch.writeFile(pathFromLabRoot, normalizePlaylist(p))
+}
+
+// Resolves every clip of a playlist in order. Clips that can't be resolved are
+// reported as broken instead of failing the whole playlist
+func (ch *ClipHandler) ResolvePlaylist(pathFromLabRoot string) (ResolvedPlaylist, error) {
+	p, err := ch.OpenPlaylist(pathFromLabRoot)
+	if err != nil {
+		return ResolvedPlaylist{}, err
+	}
+
+	rp := ResolvedPlaylist{
+		Title:  p.Title,
+		Clips:  make([]ResolvedClip, 0, len(p.Clips)),
+		Broken: make([]string, 0),
+	}
+
+	for _, c := range p.Clips {
+		rc, err := ch.ResolveClip(c)
+		if err != nil {
+			rp.Broken = append(rp.Broken, c)
+			continue
+		}
+
+		rp.Clips = append(rp.Clips, rc)
+		rp.Duration += rc.Duration
+	}
+
+	return rp, nil
+}
+
+func (ch *ClipHandler) validateClip(c Clip) error {
+	if c.In < 0 || c.Out <= c.In {
+		return ErrInvalidRange
+	}
+
+	p := filepath.Join(ch.GetLabPath(), c.Source)
+	if _, err := os.Stat(p); err != nil {
+		return ErrClipSourceNotFound
+	}
+
+	if node.DetectFileTypeFromPath(p) != node.VIDEO {
+		return ErrSourceNotAVideo
+	}
+
+	return nil
+}
+
+// Creates a new file with the extension of the file type's format. It doesn't overwrite
+// an existing one, a number is appended to its name instead
+func (ch *ClipHandler) createFile(dirFromLabRoot, name string, fileType node.FileType, content any) (node.Node, error) {
+	ext := node.Extension(fileType)
+	b, err := json.MarshalIndent(content, "", "\t")
+	if err != nil {
+		return node.Node{}, err
+	}
+
+	p := fsutil.NonDuplicatePath(filepath.Join(ch.GetLabPath(), dirFromLabRoot, name+ext), false)
+	f, err := os.OpenFile(p, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return node.Node{}, &ClipFileError{p, err}
+	}
+
+	_, err = f.Write(b)
+	f.Close()
+	if err != nil {
+		return node.Node{}, &ClipFileError{p, err}
+	}
+
+	return node.Node{
+		Name:      strings.TrimSuffix(filepath.Base(p), ext),
+		Type:      node.FILE,
+		Extension: ext,
+		FileType:  fileType,
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+func (ch *ClipHandler) readFile(pathFromLabRoot string, v any) error {
+	b, err := os.ReadFile(filepath.Join(ch.GetLabPath(), pathFromLabRoot))
+	if err != nil {
+		return &ClipFileError{pathFromLabRoot, err}
+	}
+
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return &ClipFileError{pathFromLabRoot, err}
+	}
+
+	return nil
+}
+
+func (ch *ClipHandler) writeFile(pathFromLabRoot string, v any) error {
+	p := filepath.Join(ch.GetLabPath(), pathFromLabRoot)
+	if _, err := os.Stat(p); err != nil {
+		return &ClipFileError{pathFromLabRoot, err}
+	}
+
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return &ClipFileError{pathFromLabRoot, err}
+	}
+
+	err = os.WriteFile(p, b, 0644)
+	if err != nil {
+		return &ClipFileError{pathFromLabRoot, err}
+	}
+
+	return nil
+}
+
+// Playlists can only hold clips
+func validatePlaylist(p Playlist) error {
+	for _, c := range p.Clips {
+		if node.DetectFileType(filepath.Ext(c)) != node.CLIP {
+			return &ClipFileError{c, ErrNotAClip}
+		}
+	}
+
+	return nil
+}
+
+func normalizePlaylist(p Playlist) Playlist {
+	clips := make([]string, 0, len(p.Clips))
+	for _, c := range p.Clips {
+		clips = append(clips, filepath.ToSlash(c))
+	}
+
+	p.Clips = clips
+	return p
+}
+
+// Turns a title into a file name by removing characters that file systems refuse
+func fileName(title, fallback string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < ' ' {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(title))
+
+	if name == "" {
+		return fallback
+	}
+
+	return name
+}
@@ -0,0 +1,135 @@
+package clip
+
+import (
+	"errors"
+	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/node"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testVideoName = "session.webm"
+
+// Creates a temporary lab holding an empty video
+func createTempLab(t testing.TB) (string, *ClipHandler) {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "testClips")
+	if err != nil {
+		t.Fatalf("an error occured while creating temporary directory: %v", err)
+	}
+
+	f, err := os.Create(filepath.Join(dir, testVideoName))
+	if err != nil {
+		t.Fatalf("couldn't create video: %v", err)
+	}
+	f.Close()
+
+	return dir, NewClipHandler(&config.AppConfig{
+		ConfigFile: config.ConfigFile{
+			LabPath: dir,
+		},
+	})
+}
+
+func createClipHelper(t testing.TB, ch *ClipHandler, title string, in, out float64) string {
+	t.Helper()
+
+	n, err := ch.CreateClip("", Clip{Title: title, Source: testVideoName, In: in, Out: out})
+	if err != nil {
+		t.Fatalf("couldn't create clip: %v", err)
+	}
+
+	return n.Name + n.Extension
+}
+
+func TestCreateClip(t *testing.T) {
+	t.Run("create a clip then resolve it", func(t *testing.T) {
+		dir, ch := createTempLab(t)
+		defer os.RemoveAll(dir)
+
+		p := createClipHelper(t, ch, "Whiff punish", 12.4, 15)
+		if p != "Whiff punish.clip" {
+			t.Errorf("got %s, want %s", p, "Whiff punish.clip")
+		}
+
+		rc, err := ch.ResolveClip(p)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if rc.Source != testVideoName || rc.Duration < 2.59 || rc.Duration > 2.61 {
+			t.Errorf("wrong resolved clip: %v", rc)
+		}
+	})
+
+	t.Run("clips with the same title don't overwrite each other", func(t *testing.T) {
+		dir, ch := createTempLab(t)
+		defer os.RemoveAll(dir)
+
+		createClipHelper(t, ch, "combo/drop", 1, 2)
+		p := createClipHelper(t, ch, "combo/drop", 1, 2)
+		if p != "combo-drop 1.clip" {
+			t.Errorf("got %s, want %s", p, "combo-drop 1.clip")
+		}
+	})
+
+	t.Run("invalid ranges are refused", func(t *testing.T) {
+		dir, ch := createTempLab(t)
+		defer os.RemoveAll(dir)
+
+		_, err := ch.CreateClip("", Clip{Source: testVideoName, In: 5, Out: 2})
+		if !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("got %v, want %v", err, ErrInvalidRange)
+		}
+	})
+
+	t.Run("the source must be an existing video", func(t *testing.T) {
+		dir, ch := createTempLab(t)
+		defer os.RemoveAll(dir)
+
+		_, err := ch.CreateClip("", Clip{Source: "missing.mp4", In: 0, Out: 2})
+		if !errors.Is(err, ErrClipSourceNotFound) {
+			t.Errorf("got %v, want %v", err, ErrClipSourceNotFound)
+		}
+	})
+}
+
+func TestResolvePlaylist(t *testing.T) {
+	dir, ch := createTempLab(t)
+	defer os.RemoveAll(dir)
+	c1 := createClipHelper(t, ch, "first", 0, 2)
+	c2 := createClipHelper(t, ch, "second", 10, 13)
+
+	n, err := ch.CreatePlaylist("", Playlist{Title: "Sol", Clips: []string{c2, c1, "missing.clip"}})
+	if err != nil {
+		t.Fatalf("couldn't create playlist: %v", err)
+	}
+
+	if n.FileType != node.PLAYLIST {
+		t.Errorf("got %s, want %s", n.FileType, node.PLAYLIST)
+	}
+
+	rp, err := ch.ResolvePlaylist(n.Name + n.Extension)
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if len(rp.Clips) != 2 || rp.Clips[0].Path != c2 {
+		t.Errorf("the clips are not resolved in order: %v", rp.Clips)
+	}
+
+	if len(rp.Broken) != 1 {
+		t.Errorf("want 1 broken clip, got %v", rp.Broken)
+	}
+
+	if rp.Duration != 5 {
+		t.Errorf("got a duration of %f, want 5", rp.Duration)
+	}
+
+	_, err = ch.CreatePlaylist("", Playlist{Clips: []string{testVideoName}})
+	if !errors.Is(err, ErrNotAClip) {
+		t.Errorf("got %v, want %v", err, ErrNotAClip)
+	}
+}
@@ -0,0 +1,16 @@
+package clip
+
+import "fmt"
+
+type ClipFileError struct {
+	path string
+	err  error
+}
+
+func (c *ClipFileError) Error() string {
+	return fmt.Sprintf("couldn't use %s: %v", c.path, c.err)
+}
+
+func (c *ClipFileError) Unwrap() error {
+	return c.err
+}
@@ -0,0 +1,135 @@
+package combos
+
+import (
+	"context"
+	"errors"
+	"flow-poc/backend/db"
+	"flow-poc/backend/db/repository"
+)
+
+var (
+	ErrStarterOfAnotherCharacter = errors.New("a combo must start with a move of its character")
+	ErrNegativeComboValue        = errors.New("the damage, corner carry and meter of a combo can't be negative")
+)
+
+// Combos a character can land after one of its moves, the starter. They're deleted along
+// with their character or their starter. Oki is the frame advantage once the combo ends and
+// meter is how much meter it spends, in the unit of the game
+type ComboRepository struct {
+	conn *db.Conn
+}
+
+func NewComboRepository(conn *db.Conn) *ComboRepository {
+	return &ComboRepository{
+		conn,
+	}
+}
+
+func checkCombo(ctx context.Context, q *repository.Queries, characterId, starterId, damage, cornerCarry, meter int64) error {
+	if damage < 0 || cornerCarry < 0 || meter < 0 {
+		return ErrNegativeComboValue
+	}
+
+	starter, err := q.GetOneMove(ctx, starterId)
+	if err != nil {
+		return err
+	}
+
+	if starter.Characterid != characterId {
+		return ErrStarterOfAnotherCharacter
+	}
+
+	return nil
+}
+
+func (cr *ComboRepository) AddCombo(newCombo repository.AddComboParams) (repository.Combo, error) {
+	ctx := context.Background()
+	q, err := cr.conn.Queries()
+	if err != nil {
+		return repository.Combo{}, err
+	}
+
+	err = checkCombo(ctx, q, newCombo.Characterid, newCombo.Starterid, newCombo.Damage, newCombo.Cornercarry, newCombo.Meter)
+	if err != nil {
+		return repository.Combo{}, err
+	}
+
+	combo, err := q.AddCombo(ctx, newCombo)
+	if err != nil {
+		return repository.Combo{}, err
+	}
+
+	return combo, nil
+}
+
+func (cr *ComboRepository) GetOneCombo(id int64) (repository.Combo, error) {
+	ctx := context.Background()
+	q, err := cr.conn.Queries()
+	if err != nil {
+		return repository.Combo{}, err
+	}
+
+	combo, err := q.GetOneCombo(ctx, id)
+	if err != nil {
+		return repository.Combo{}, err
+	}
+
+	return combo, nil
+}
+
+// Returns the combos of a character, the most damaging first
+func (cr *ComboRepository) ListCombos(characterId int64) ([]repository.Combo, error) {
+	ctx := context.Background()
+	q, err := cr.conn.Queries()
+	if err != nil {
+		return []repository.Combo{}, err
+	}
+
+	combos, err := q.ListCombos(ctx, characterId)
+	if err != nil {
+		return []repository.Combo{}, err
+	}
+
+	return combos, err
+}
+
+// Edits everything but the character of the combo
+func (cr *ComboRepository) UpdateCombo(editedCombo repository.EditComboParams) error {
+	ctx := context.Background()
+	q, err := cr.conn.Queries()
+	if err != nil {
+		return err
+	}
+
+	combo, err := q.GetOneCombo(ctx, editedCombo.ID)
+	if err != nil {
+		return err
+	}
+
+	err = checkCombo(ctx, q, combo.Characterid, editedCombo.Starterid, editedCombo.Damage, editedCombo.Cornercarry, editedCombo.Meter)
+	if err != nil {
+		return err
+	}
+
+	err = q.EditCombo(ctx, editedCombo)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+func (cr *ComboRepository) DeleteCombo(id int64) error {
+	ctx := context.Background()
+	q, err := cr.conn.Queries()
+	if err != nil {
+		return err
+	}
+
+	err = q.DeleteCombo(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
@@ -0,0 +1,67 @@
+package combos
+
+import (
+	"errors"
+	"flow-poc/backend/characters"
+	"flow-poc/backend/db/dbtest"
+	"flow-poc/backend/db/repository"
+	"flow-poc/backend/games"
+	"flow-poc/backend/moves"
+	"testing"
+)
+
+func TestComboRepository(t *testing.T) {
+	conn := dbtest.OpenTemp(t)
+	game, err := games.NewGameRepository(conn).AddGame(repository.AddGameParams{Name: "Guilty Gear Strive"})
+	if err != nil {
+		t.Fatalf("couldn't add game: %v", err)
+	}
+
+	chars := characters.NewCharacterRepository(conn)
+	sol, err := chars.AddCharacter(repository.AddCharacterParams{Gameid: game.ID, Name: "Sol Badguy"})
+	if err != nil {
+		t.Fatalf("couldn't add character: %v", err)
+	}
+
+	ky, err := chars.AddCharacter(repository.AddCharacterParams{Gameid: game.ID, Name: "Ky Kiske"})
+	if err != nil {
+		t.Fatalf("couldn't add character: %v", err)
+	}
+
+	mr := moves.NewMoveRepository(conn)
+	fS, err := mr.AddMove(repository.AddMoveParams{Characterid: sol.ID, Notation: "f.S", Guard: string(moves.ALL)})
+	if err != nil {
+		t.Fatalf("couldn't add move: %v", err)
+	}
+
+	cr := NewComboRepository(conn)
+	bnb, err := cr.AddCombo(repository.AddComboParams{Characterid: sol.ID, Starterid: fS.ID, Name: "BnB", Notation: "f.S > 2H > 236K", Damage: 140, Cornercarry: 40, Oki: 20})
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	t.Run("a combo starts with a move of its character", func(t *testing.T) {
+		_, err := cr.AddCombo(repository.AddComboParams{Characterid: ky.ID, Starterid: fS.ID, Name: "Not Ky's"})
+		if !errors.Is(err, ErrStarterOfAnotherCharacter) {
+			t.Errorf("got %v, want %v", err, ErrStarterOfAnotherCharacter)
+		}
+	})
+
+	t.Run("negative values", func(t *testing.T) {
+		err := cr.UpdateCombo(repository.EditComboParams{ID: bnb.ID, Starterid: fS.ID, Damage: -1})
+		if !errors.Is(err, ErrNegativeComboValue) {
+			t.Errorf("got %v, want %v", err, ErrNegativeComboValue)
+		}
+	})
+
+	t.Run("deleting the starter deletes the combo", func(t *testing.T) {
+		if err := mr.DeleteMove(fS.ID); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		combos, err := cr.ListCombos(sol.ID)
+		if err != nil || len(combos) != 0 {
+			t.Errorf("the combo should have been deleted: %+v, %v", combos, err)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"slices"
+)
+
+// A change of the configuration, sent to every subscriber
+type Change struct {
+	// Lab opened before and after the change. They're the same if the lab didn't change.
+	// PreviousLab is empty when no lab was opened before
+	PreviousLab string
+	Lab         string
+	// Settings in effect before and after the change
+	PreviousSettings Settings
+	Settings         Settings
+}
+
+func (c Change) LabSwitched() bool {
+	return !sameLab(c.PreviousLab, c.Lab)
+}
+
+func (c Change) SettingsChanged() bool {
+	return c.PreviousSettings != c.Settings
+}
+
+// Registers a function called after each change of the current lab or of the settings in
+// effect. Subscribers are called one after the other in the order they subscribed, from the
+// goroutine that made the change
+func (ac *AppConfig) Subscribe(fn func(Change) error) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.subscribers = append(ac.subscribers, fn)
+}
+
+// Returns the lab and the settings in effect. Called with ac.mu held
+func (ac *AppConfig) state() Change {
+	return Change{
+		Lab:      ac.ConfigFile.LabPath,
+		Settings: ac.lab.apply(ac.ConfigFile.Settings),
+	}
+}
+
+// Builds the change between before and the current state. Called with ac.mu held
+func (ac *AppConfig) changeSince(before Change) Change {
+	c := ac.state()
+	c.PreviousLab = before.Lab
+	c.PreviousSettings = before.Settings
+	return c
+}
+
+// Sends a change to the subscribers if something changed. Must be called without ac.mu held
+// since subscribers usually read the configuration
+func (ac *AppConfig) publish(c Change) error {
+	if !c.LabSwitched() && !c.SettingsChanged() {
+		return nil
+	}
+
+	ac.mu.Lock()
+	subscribers := slices.Clone(ac.subscribers)
+	ac.mu.Unlock()
+
+	errs := make([]error, 0)
+	for _, fn := range subscribers {
+		if err := fn(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	paths := createTempPaths(t)
+	os.MkdirAll(paths.ConfigDir, os.ModePerm)
+	lab, err := os.MkdirTemp("", "testLabChanges")
+	if err != nil {
+		t.Fatalf("couldn't create lab: %v", err)
+	}
+	defer os.RemoveAll(lab)
+
+	ac := NewAppConfig(paths)
+	changes := make([]Change, 0)
+	ac.Subscribe(func(c Change) error {
+		changes = append(changes, c)
+		return nil
+	})
+
+	t.Run("opening a lab", func(t *testing.T) {
+		ac.CreateAppConfig(lab)
+		if len(changes) != 1 || !changes[0].LabSwitched() || changes[0].PreviousLab != "" || changes[0].Lab != lab {
+			t.Fatalf("wrong changes: %+v", changes)
+		}
+
+		if err := ac.SwitchLab(lab); err != nil || len(changes) != 1 {
+			t.Errorf("opening the same lab again shouldn't publish anything: %v, %+v", err, changes)
+		}
+	})
+
+	t.Run("saving settings", func(t *testing.T) {
+		s := ac.GetGlobalSettings()
+		if err := ac.SaveSettings(s); err != nil || len(changes) != 1 {
+			t.Fatalf("saving the same settings shouldn't publish anything: %v, %+v", err, changes)
+		}
+
+		s.General.WatcherInterval = 500
+		if err := ac.SaveSettings(s); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		c := changes[len(changes)-1]
+		if len(changes) != 2 || c.LabSwitched() || !c.SettingsChanged() || c.Settings.General.WatcherInterval != 500 {
+			t.Errorf("wrong change: %+v", c)
+		}
+
+		folder := "Médias"
+		if err := ac.SaveLabSettings(LabSettings{Media: LabMediaSettings{MediaFolder: &folder}}); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		c = changes[len(changes)-1]
+		if len(changes) != 3 || c.PreviousSettings.Media.MediaFolder != "" || c.Settings.Media.MediaFolder != folder {
+			t.Errorf("wrong change: %+v", c)
+		}
+	})
+
+	t.Run("editing the config file", func(t *testing.T) {
+		time.Sleep(10 * time.Millisecond)
+		err := os.WriteFile(paths.ConfigFile(), []byte("labpath = \""+lab+"\"\n\n[settings.general]\ntheme = \"DARK\"\n"), 0644)
+		if err != nil {
+			t.Fatalf("couldn't edit the config file: %v", err)
+		}
+
+		if _, err := ac.reloadIfEdited(); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		c := changes[len(changes)-1]
+		if len(changes) != 4 || c.Settings.General.Theme != DARK || c.Settings.General.WatcherInterval != DefaultSettings().General.WatcherInterval {
+			t.Errorf("wrong change: %+v", c)
+		}
+	})
+
+	t.Run("errors of subscribers are returned", func(t *testing.T) {
+		errSubscriber := errors.New("subscriber failed")
+		ac.Subscribe(func(c Change) error {
+			return errSubscriber
+		})
+
+		s := ac.GetGlobalSettings()
+		s.General.Theme = LIGHT
+		if err := ac.SaveSettings(s); !errors.Is(err, errSubscriber) {
+			t.Errorf("got %v, want %v", err, errSubscriber)
+		}
+
+		if len(changes) != 5 {
+			t.Errorf("every subscriber should be called: %+v", changes)
+		}
+	})
+}
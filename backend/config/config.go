@@ -6,6 +6,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/wailsapp/wails/v2/pkg/logger"
@@ -15,18 +17,38 @@ import (
 const configFileName = "config.toml"
 
 type ConfigFile struct {
+	// Lab currently opened
 	LabPath string `toml:"labpath"`
+	// Every lab known by the app, including the current one
+	Labs     []Lab    `toml:"labs"`
+	Settings Settings `toml:"settings"`
 }
 
 type AppConfig struct {
 	Ctx        context.Context
 	Logger     logger.Logger
 	ConfigFile ConfigFile
+	// Where the config file is stored
+	Paths Paths
+
+	// mu protects the labs, the settings and the subscribers
+	mu          sync.Mutex
+	subscribers []func(Change) error
+	// Invalid settings found in the config file, replaced by their default value
+	settingsErrors []FieldError
+	// Modification time of the config file when the app last read or wrote it
+	modTime time.Time
+	// Settings of the current lab's lab.toml, layered over ConfigFile.Settings
+	lab        LabSettings
+	labErrors  []FieldError
+	labModTime time.Time
 }
 
-func NewAppConfig() *AppConfig {
+func NewAppConfig(paths Paths) *AppConfig {
 	ac := AppConfig{
-		Logger: logger.NewDefaultLogger(),
+		Logger:     logger.NewDefaultLogger(),
+		Paths:      paths,
+		ConfigFile: ConfigFile{Settings: DefaultSettings()},
 	}
 	ac.CheckConfigPresenceAndLoadIt()
 
@@ -38,6 +60,9 @@ func (ac *AppConfig) SetContext(ctx context.Context) {
 }
 
 func (ac *AppConfig) SetConfigFile(cfg ConfigFile) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
 	ac.ConfigFile = cfg
 }
 
@@ -60,38 +85,42 @@ func (ac *AppConfig) OpenCreateLabDialog() (string, error) {
 	return dir, nil
 }
 
+// Creates a lab in the given directory and opens it
 func (ac *AppConfig) CreateAppConfig(configDirPath string) {
-	config := ConfigFile{
-		LabPath: configDirPath,
+	_, err := ac.AddLab(filepath.Base(configDirPath), configDirPath)
+	if err != nil && !errors.Is(err, ErrLabExists) {
+		ac.Logger.Error(err.Error())
+		return
 	}
 
-	go func() {
-		p := filepath.Join(configDirPath, ".labmonster")
-		// Creating the .labmonster config directory if it doesn't exists
-		err := os.MkdirAll(p, os.ModePerm)
-		if err != nil {
-			ac.Logger.Error(err.Error())
-		}
-	}()
-
-	data, err := toml.Marshal(config)
+	err = ac.SwitchLab(configDirPath)
 	if err != nil {
 		ac.Logger.Error(err.Error())
-		return
 	}
+}
 
-	err = os.WriteFile(configFileName, data, os.ModePerm)
+func (ac *AppConfig) saveConfigFile() error {
+	data, err := toml.Marshal(ac.ConfigFile)
 	if err != nil {
-		ac.Logger.Error(err.Error())
-		return
+		return err
 	}
 
-	ac.SetConfigFile(config)
+	err = os.WriteFile(ac.Paths.ConfigFile(), data, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// The app's own writes must not be seen as an edit
+	if info, err := os.Stat(ac.Paths.ConfigFile()); err == nil {
+		ac.modTime = info.ModTime()
+	}
+
+	return nil
 }
 
 // Vérifie la présence du fichie de configuration et le charge si c'est le cas
 func (ac *AppConfig) CheckConfigPresenceAndLoadIt() bool {
-	if _, err := os.Stat(configFileName); errors.Is(err, os.ErrNotExist) {
+	if _, err := os.Stat(ac.Paths.ConfigFile()); errors.Is(err, os.ErrNotExist) {
 		ac.Logger.Error(err.Error())
 		return false
 	}
@@ -101,26 +130,54 @@ func (ac *AppConfig) CheckConfigPresenceAndLoadIt() bool {
 
 // Charge le fichier de configuration
 func (ac *AppConfig) LoadConfigFile() {
-	f, err := os.Open(configFileName)
+	cfg, settingsErrors, modTime, err := ac.readConfigFile()
 	if err != nil {
 		ac.Logger.Error(err.Error())
 		return
 	}
 
+	ac.SetConfigFile(cfg)
+
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.settingsErrors = settingsErrors
+	ac.modTime = modTime
+	if err := ac.loadLabSettings(); err != nil {
+		ac.Logger.Error(err.Error())
+	}
+}
+
+// Reads the config file. Missing settings get their default value, invalid ones are
+// replaced by their default value and returned as errors
+func (ac *AppConfig) readConfigFile() (ConfigFile, []FieldError, time.Time, error) {
+	f, err := os.Open(ac.Paths.ConfigFile())
+	if err != nil {
+		return ConfigFile{}, nil, time.Time{}, err
+	}
+
 	defer f.Close()
 
+	info, err := f.Stat()
+	if err != nil {
+		return ConfigFile{}, nil, time.Time{}, err
+	}
+
 	data, err := io.ReadAll(f)
 	if err != nil {
-		ac.Logger.Error(err.Error())
-		return
+		return ConfigFile{}, nil, time.Time{}, err
 	}
 
-	cfg := ConfigFile{}
+	cfg := ConfigFile{Settings: DefaultSettings()}
 	err = toml.Unmarshal(data, &cfg)
 	if err != nil {
-		ac.Logger.Error(err.Error())
-		return
+		return ConfigFile{}, nil, time.Time{}, err
 	}
 
-	ac.SetConfigFile(cfg)
+	// Config files written before the app knew several labs only have the current one
+	if cfg.LabPath != "" && indexOfLab(cfg.Labs, cfg.LabPath) == -1 {
+		cfg.Labs = append(cfg.Labs, Lab{Name: filepath.Base(cfg.LabPath), Path: cfg.LabPath})
+	}
+
+	return cfg, cfg.Settings.fix(), info.ModTime(), nil
 }
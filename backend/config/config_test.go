@@ -1,22 +1,35 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 )
 
-func TestCheckConfigPresence(t *testing.T) {
-	ac := NewAppConfig()
-	wd, err := os.Getwd()
+func createTempPaths(t testing.TB) Paths {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "testConfig")
 	if err != nil {
-		t.Error("cannot retrieve working directory")
+		t.Fatalf("couldn't create temporary directory: %v", err)
 	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
 
-	defer os.Remove(filepath.Join(wd, configFileName))
-	defer os.Remove(filepath.Join(wd, ".labmonster"))
+	return Paths{ConfigDir: filepath.Join(dir, "config"), DataDir: filepath.Join(dir, "data")}
+}
 
-	ac.CreateAppConfig(wd)
+func TestCheckConfigPresence(t *testing.T) {
+	paths := createTempPaths(t)
+	os.MkdirAll(paths.ConfigDir, os.ModePerm)
+	ac := NewAppConfig(paths)
+	lab, err := os.MkdirTemp("", "testLab")
+	if err != nil {
+		t.Fatalf("couldn't create lab: %v", err)
+	}
+	defer os.RemoveAll(lab)
+
+	ac.CreateAppConfig(lab)
 	want := true
 	got := ac.CheckConfigPresenceAndLoadIt()
 
@@ -24,3 +37,159 @@ func TestCheckConfigPresence(t *testing.T) {
 		t.Error("config was not found")
 	}
 }
+
+func TestSwitchLab(t *testing.T) {
+	paths := createTempPaths(t)
+	os.MkdirAll(paths.ConfigDir, os.ModePerm)
+
+	sol, err := os.MkdirTemp("", "testLabSol")
+	if err != nil {
+		t.Fatalf("couldn't create lab: %v", err)
+	}
+	defer os.RemoveAll(sol)
+
+	ky, err := os.MkdirTemp("", "testLabKy")
+	if err != nil {
+		t.Fatalf("couldn't create lab: %v", err)
+	}
+	defer os.RemoveAll(ky)
+
+	ac := &AppConfig{Paths: paths}
+	switches := make([]string, 0)
+	ac.Subscribe(func(c Change) error {
+		if c.LabSwitched() {
+			switches = append(switches, c.PreviousLab+" -> "+c.Lab)
+		}
+		return nil
+	})
+
+	for _, p := range []string{sol, ky} {
+		if _, err := ac.AddLab(filepath.Base(p), p); err != nil {
+			t.Fatalf("couldn't add lab: %v", err)
+		}
+	}
+
+	if _, err := ac.AddLab("Sol", sol); !errors.Is(err, ErrLabExists) {
+		t.Errorf("got %v, want %v", err, ErrLabExists)
+	}
+
+	if err := ac.SwitchLab(sol); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	// Services read the lab from their own goroutines while it's switched
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			ac.LabPath()
+		}
+	}()
+
+	if err := ac.SwitchLab(ky); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+	<-done
+
+	if ac.LabPath() != ky || len(switches) != 2 || switches[1] != sol+" -> "+ky {
+		t.Errorf("wrong switches: %v", switches)
+	}
+
+	if labs := ac.GetLabs(); labs[0].Path != ky {
+		t.Errorf("the last opened lab should come first: %v", labs)
+	}
+
+	if err := ac.RemoveLab(ky); !errors.Is(err, ErrRemoveCurrentLab) {
+		t.Errorf("got %v, want %v", err, ErrRemoveCurrentLab)
+	}
+
+	if err := ac.RemoveLab(sol); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	// The saved configuration keeps the labs
+	loaded := &AppConfig{Logger: ac.Logger, Paths: paths}
+	loaded.LoadConfigFile()
+	if loaded.ConfigFile.LabPath != ky || len(loaded.ConfigFile.Labs) != 1 {
+		t.Errorf("wrong saved configuration: %+v", loaded.ConfigFile)
+	}
+}
+
+func TestResolvePaths(t *testing.T) {
+	paths := createTempPaths(t)
+	t.Setenv(ConfigDirEnv, paths.ConfigDir)
+
+	got, err := ResolvePaths([]string{"-psn_0_1234", "--data-dir", paths.DataDir})
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if got != paths {
+		t.Errorf("got %+v, want %+v", got, paths)
+	}
+
+	if _, err := os.Stat(got.DataDir); err != nil {
+		t.Errorf("the data directory was not created: %v", err)
+	}
+}
+
+func TestMigrateFrom(t *testing.T) {
+	paths := createTempPaths(t)
+	os.MkdirAll(paths.ConfigDir, os.ModePerm)
+	os.MkdirAll(paths.DataDir, os.ModePerm)
+
+	legacy, err := os.MkdirTemp("", "testLegacy")
+	if err != nil {
+		t.Fatalf("couldn't create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(legacy)
+
+	os.WriteFile(filepath.Join(legacy, configFileName), []byte("labpath = \"/old\"\n"), 0644)
+	os.WriteFile(filepath.Join(legacy, dbFileName), []byte("db"), 0644)
+	os.WriteFile(paths.ConfigFile(), []byte("labpath = \"/new\"\n"), 0644)
+
+	if err := paths.MigrateFrom(legacy); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if b, _ := os.ReadFile(paths.ConfigFile()); string(b) != "labpath = \"/new\"\n" {
+		t.Errorf("an existing config file shouldn't be replaced, got %s", b)
+	}
+
+	if b, _ := os.ReadFile(paths.SeedDatabase()); string(b) != "db" {
+		t.Errorf("the database was not moved, got %s", b)
+	}
+
+	if _, err := os.Stat(filepath.Join(legacy, dbFileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the old database should have been removed: %v", err)
+	}
+}
+
+func TestMigrateLabDatabase(t *testing.T) {
+	paths := createTempPaths(t)
+	lab := t.TempDir()
+	os.MkdirAll(filepath.Join(lab, ".labmonster"), os.ModePerm)
+	os.WriteFile(filepath.Join(lab, ".labmonster", dbFileName), []byte("lab db"), 0644)
+
+	if err := paths.MigrateLabDatabase(lab); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if b, _ := os.ReadFile(paths.LabDatabase(lab)); string(b) != "lab db" {
+		t.Errorf("the database was not moved, got %s", b)
+	}
+
+	if paths.LabDatabase(lab) == paths.LabDatabase(t.TempDir()) {
+		t.Error("two labs share the same database")
+	}
+
+	// A database created by this version is kept
+	os.WriteFile(filepath.Join(lab, ".labmonster", dbFileName), []byte("old db"), 0644)
+	if err := paths.MigrateLabDatabase(lab); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if b, _ := os.ReadFile(paths.LabDatabase(lab)); string(b) != "lab db" {
+		t.Errorf("an existing database shouldn't be replaced, got %s", b)
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+var (
+	ErrLabExists        = errors.New("this lab is already known")
+	ErrLabNotFound      = errors.New("this lab is not known")
+	ErrRemoveCurrentLab = errors.New("the current lab can't be removed")
+	ErrEmptyLabName     = errors.New("a lab needs a name")
+)
+
+type Lab struct {
+	Name string `toml:"name" json:"name"`
+	// Absolute path to the lab's directory
+	Path       string    `toml:"path" json:"path"`
+	LastOpened time.Time `toml:"lastopened" json:"lastOpened"`
+}
+
+// Returns every known lab, the most recently opened first
+func (ac *AppConfig) GetLabs() []Lab {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	labs := slices.Clone(ac.ConfigFile.Labs)
+	slices.SortStableFunc(labs, func(a, b Lab) int {
+		return b.LastOpened.Compare(a.LastOpened)
+	})
+
+	return labs
+}
+
+// Adds an existing directory to the known labs without opening it
+func (ac *AppConfig) AddLab(name, labPath string) (Lab, error) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Lab{}, ErrEmptyLabName
+	}
+
+	labPath, err := filepath.Abs(labPath)
+	if err != nil {
+		return Lab{}, err
+	}
+
+	if indexOfLab(ac.ConfigFile.Labs, labPath) != -1 {
+		return Lab{}, fmt.Errorf("couldn't add %s: %w", labPath, ErrLabExists)
+	}
+
+	info, err := os.Stat(labPath)
+	if err != nil {
+		return Lab{}, fmt.Errorf("couldn't add %s: %w", labPath, err)
+	}
+
+	if !info.IsDir() {
+		return Lab{}, fmt.Errorf("couldn't add %s: not a directory", labPath)
+	}
+
+	// Creating the .labmonster config directory if it doesn't exists
+	err = os.MkdirAll(filepath.Join(labPath, ".labmonster"), os.ModePerm)
+	if err != nil {
+		return Lab{}, err
+	}
+
+	lab := Lab{Name: name, Path: labPath}
+	ac.ConfigFile.Labs = append(ac.ConfigFile.Labs, lab)
+
+	return lab, ac.saveConfigFile()
+}
+
+// Forgets a lab. Its directory is left untouched
+func (ac *AppConfig) RemoveLab(labPath string) error {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	i := indexOfLab(ac.ConfigFile.Labs, labPath)
+	if i == -1 {
+		return fmt.Errorf("couldn't remove %s: %w", labPath, ErrLabNotFound)
+	}
+
+	if sameLab(ac.ConfigFile.LabPath, labPath) {
+		return fmt.Errorf("couldn't remove %s: %w", labPath, ErrRemoveCurrentLab)
+	}
+
+	ac.ConfigFile.Labs = slices.Delete(ac.ConfigFile.Labs, i, i+1)
+	return ac.saveConfigFile()
+}
+
+// Returns the path of the opened lab, empty when no lab is opened. The lab can be switched at
+// any time, so everything outside of this package must read it from here rather than from
+// ConfigFile
+func (ac *AppConfig) LabPath() string {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	return ac.ConfigFile.LabPath
+}
+
+// Opens a known lab. Subscribers are told once the configuration is saved, so the app
+// follows the new lab without restarting
+func (ac *AppConfig) SwitchLab(labPath string) error {
+	ac.mu.Lock()
+
+	i := indexOfLab(ac.ConfigFile.Labs, labPath)
+	if i == -1 {
+		ac.mu.Unlock()
+		return fmt.Errorf("couldn't open %s: %w", labPath, ErrLabNotFound)
+	}
+
+	before := ac.state()
+	ac.ConfigFile.Labs[i].LastOpened = time.Now()
+	ac.ConfigFile.LabPath = ac.ConfigFile.Labs[i].Path
+	lab := ac.ConfigFile.Labs[i]
+
+	err := ac.saveConfigFile()
+	// Subscribers must see the settings of the new lab
+	labErr := ac.loadLabSettings()
+	change := ac.changeSince(before)
+	ac.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if labErr != nil {
+		ac.Logger.Error(labErr.Error())
+	}
+
+	err = ac.publish(change)
+
+	if change.LabSwitched() && ac.Ctx != nil {
+		runtime.EventsEmit(ac.Ctx, "labswitched", lab)
+	}
+
+	return err
+}
+
+func indexOfLab(labs []Lab, labPath string) int {
+	return slices.IndexFunc(labs, func(l Lab) bool {
+		return sameLab(l.Path, labPath)
+	})
+}
+
+func sameLab(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+
+	return filepath.Clean(a) == filepath.Clean(b)
+}
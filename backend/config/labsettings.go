@@ -0,0 +1,185 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const labSettingsFileName = "lab.toml"
+
+// Settings that belong to a lab rather than to the machine. They're stored in the lab's
+// .labmonster/lab.toml so a lab copied to another machine keeps its behavior. A nil field
+// isn't set by the lab: the value of config.toml is used, or the default value if
+// config.toml doesn't set it either
+type LabSettings struct {
+	Graph LabGraphSettings `toml:"graph" json:"graph"`
+	Media LabMediaSettings `toml:"media" json:"media"`
+	Game  LabGameSettings  `toml:"game" json:"game"`
+}
+
+type LabGraphSettings struct {
+	DefaultTemplate *string `toml:"defaulttemplate,omitempty" json:"defaultTemplate"`
+}
+
+type LabMediaSettings struct {
+	MediaFolder *string `toml:"mediafolder,omitempty" json:"mediaFolder"`
+}
+
+type LabGameSettings struct {
+	DefaultGame *int64 `toml:"defaultgame,omitempty" json:"defaultGame"`
+}
+
+// Returns s with the values set by the lab
+func (l LabSettings) apply(s Settings) Settings {
+	if l.Graph.DefaultTemplate != nil {
+		s.Graph.DefaultTemplate = *l.Graph.DefaultTemplate
+	}
+
+	if l.Media.MediaFolder != nil {
+		s.Media.MediaFolder = *l.Media.MediaFolder
+	}
+
+	if l.Game.DefaultGame != nil {
+		s.Game.DefaultGame = *l.Game.DefaultGame
+	}
+
+	return s
+}
+
+// Unsets every invalid value, so the global one is used instead, and returns the errors found.
+// Fields of the errors are prefixed with lab.
+func (l *LabSettings) fix() []FieldError {
+	probe := l.apply(DefaultSettings())
+	errs := probe.Validate()
+	for i, e := range errs {
+		switch e.Field {
+		case "graph.defaultTemplate":
+			l.Graph.DefaultTemplate = nil
+		case "media.mediaFolder":
+			l.Media.MediaFolder = nil
+		case "game.defaultGame":
+			l.Game.DefaultGame = nil
+		}
+
+		errs[i].Field = "lab." + e.Field
+	}
+
+	return errs
+}
+
+func labSettingsPath(labPath string) string {
+	return filepath.Join(labPath, ".labmonster", labSettingsFileName)
+}
+
+// Reads the lab.toml of a lab. A lab without lab.toml sets nothing
+func readLabSettings(labPath string) (LabSettings, []FieldError, time.Time, error) {
+	var l LabSettings
+	if labPath == "" {
+		return l, nil, time.Time{}, nil
+	}
+
+	p := labSettingsPath(labPath)
+	info, err := os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil, time.Time{}, nil
+	}
+
+	if err != nil {
+		return l, nil, time.Time{}, err
+	}
+
+	_, err = toml.DecodeFile(p, &l)
+	if err != nil {
+		return LabSettings{}, nil, info.ModTime(), err
+	}
+
+	return l, l.fix(), info.ModTime(), nil
+}
+
+// Reads the lab.toml of the current lab. Called with ac.mu held
+func (ac *AppConfig) loadLabSettings() error {
+	l, errs, modTime, err := readLabSettings(ac.ConfigFile.LabPath)
+	ac.labModTime = modTime
+	if err != nil {
+		ac.lab = LabSettings{}
+		ac.labErrors = nil
+		return err
+	}
+
+	ac.lab = l
+	ac.labErrors = errs
+	return nil
+}
+
+// Returns the settings of config.toml, without the ones set by the current lab.
+// This is what SaveSettings saves
+func (ac *AppConfig) GetGlobalSettings() Settings {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	return ac.ConfigFile.Settings
+}
+
+// Returns the settings set by the current lab
+func (ac *AppConfig) GetLabSettings() LabSettings {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	return ac.lab
+}
+
+// Saves the settings of the current lab in its lab.toml if they're all valid. Otherwise
+// returns a SettingsError listing them
+func (ac *AppConfig) SaveLabSettings(l LabSettings) error {
+	probe := l
+	if errs := probe.fix(); len(errs) != 0 {
+		return &SettingsError{errs}
+	}
+
+	ac.mu.Lock()
+	before := ac.state()
+	err := ac.writeLabSettings(l)
+	change := ac.changeSince(before)
+	ac.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return ac.publish(change)
+}
+
+// Called with ac.mu held
+func (ac *AppConfig) writeLabSettings(l LabSettings) error {
+	if ac.ConfigFile.LabPath == "" {
+		return ErrLabNotFound
+	}
+
+	p := labSettingsPath(ac.ConfigFile.LabPath)
+	err := os.MkdirAll(filepath.Dir(p), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	data, err := toml.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(p, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	ac.lab = l
+	ac.labErrors = nil
+	if info, err := os.Stat(p); err == nil {
+		ac.labModTime = info.ModTime()
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flow-poc/backend/filesystem/fsutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// Name of the app's directory inside the user's config and data directories
+	appDirName = "labmonster"
+	// Database of a lab. Older versions created one next to the executable, it's now used
+	// as the starting point of the database of labs that don't have one yet
+	dbFileName = "core.db"
+	// Directory inside the data directory holding a directory per lab
+	labsDirName = "labs"
+
+	ConfigDirEnv = "LABMONSTER_CONFIG_DIR"
+	DataDirEnv   = "LABMONSTER_DATA_DIR"
+)
+
+// Directories where the app stores what doesn't belong to a lab
+type Paths struct {
+	ConfigDir string
+	DataDir   string
+}
+
+// Resolves the app's directories. A directory given with the --config-dir or --data-dir flag
+// comes first, then the LABMONSTER_CONFIG_DIR and LABMONSTER_DATA_DIR environment variables,
+// then the user's directories of the OS (XDG on Linux). Unknown arguments are ignored since
+// the OS or Wails can add their own
+func ResolvePaths(args []string) (Paths, error) {
+	p := Paths{
+		ConfigDir: flagValue(args, "config-dir", os.Getenv(ConfigDirEnv)),
+		DataDir:   flagValue(args, "data-dir", os.Getenv(DataDirEnv)),
+	}
+
+	if p.ConfigDir == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return Paths{}, err
+		}
+		p.ConfigDir = filepath.Join(dir, appDirName)
+	}
+
+	if p.DataDir == "" {
+		dir, err := userDataDir()
+		if err != nil {
+			return Paths{}, err
+		}
+		p.DataDir = filepath.Join(dir, appDirName)
+	}
+
+	for _, dir := range []string{p.ConfigDir, p.DataDir} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return Paths{}, err
+		}
+	}
+
+	return p, nil
+}
+
+// Returns the value of a flag given as -name value, --name value or --name=value, or
+// fallback if the flag wasn't given
+func flagValue(args []string, name, fallback string) string {
+	for i, a := range args {
+		if !strings.HasPrefix(a, "-") {
+			continue
+		}
+
+		k, v, found := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if k != name {
+			continue
+		}
+
+		if found {
+			return v
+		}
+
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+
+	return fallback
+}
+
+func (p Paths) ConfigFile() string {
+	return filepath.Join(p.ConfigDir, configFileName)
+}
+
+// Database copied into labs that don't have one yet
+func (p Paths) SeedDatabase() string {
+	return filepath.Join(p.DataDir, dbFileName)
+}
+
+// Database of the given lab, stored in the data directory like the rest of the app's data.
+// Each lab has its own directory there, named after a hash of the lab's absolute path
+func (p Paths) LabDatabase(labPath string) string {
+	return filepath.Join(p.DataDir, labsDirName, labDirName(labPath), dbFileName)
+}
+
+func labDirName(labPath string) string {
+	if abs, err := filepath.Abs(labPath); err == nil {
+		labPath = abs
+	}
+
+	sum := sha256.Sum256([]byte(filepath.Clean(labPath)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Moves the database older versions kept in the .labmonster directory of the lab to the data
+// directory. A database already present in the data directory is never replaced
+func (p Paths) MigrateLabDatabase(labPath string) error {
+	src := filepath.Join(labPath, ".labmonster", dbFileName)
+	dst := p.LabDatabase(labPath)
+	if !fsutil.Exists(src) || fsutil.Exists(dst) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+
+	return moveFile(src, dst)
+}
+
+// Moves the config and the database created by older versions in the given directory,
+// the working directory they were launched from, to the app's directories. Files already
+// present in the app's directories are never replaced, so this only happens once
+func (p Paths) MigrateFrom(legacyDir string) error {
+	errs := make([]error, 0)
+	for src, dst := range map[string]string{
+		filepath.Join(legacyDir, configFileName): p.ConfigFile(),
+		filepath.Join(legacyDir, dbFileName):     p.SeedDatabase(),
+	} {
+		if !fsutil.Exists(src) || fsutil.Exists(dst) || samePath(src, dst) {
+			continue
+		}
+
+		errs = append(errs, moveFile(src, dst))
+	}
+
+	return errors.Join(errs...)
+}
+
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !fsutil.IsCrossDevice(err) {
+		return err
+	}
+
+	if err := fsutil.CopyFile(src, dst); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+func samePath(a, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && a == b
+}
+
+// Same as os.UserConfigDir for the data of the app
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return "", errors.New("%LocalAppData% is not defined")
+	case "darwin", "ios":
+		return os.UserConfigDir()
+	default:
+		if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+			return dir, nil
+		}
+
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "share"), nil
+	}
+}
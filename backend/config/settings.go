@@ -0,0 +1,375 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+type Theme string
+
+const (
+	LIGHT  Theme = "LIGHT"
+	DARK   Theme = "DARK"
+	SYSTEM Theme = "SYSTEM"
+)
+
+var Themes = []struct {
+	Value  Theme
+	TSName string
+}{
+	{LIGHT, "LIGHT"},
+	{DARK, "DARK"},
+	{SYSTEM, "SYSTEM"},
+}
+
+type RecordingQuality string
+
+const (
+	LOW    RecordingQuality = "LOW"
+	MEDIUM RecordingQuality = "MEDIUM"
+	HIGH   RecordingQuality = "HIGH"
+)
+
+var RecordingQualities = []struct {
+	Value  RecordingQuality
+	TSName string
+}{
+	{LOW, "LOW"},
+	{MEDIUM, "MEDIUM"},
+	{HIGH, "HIGH"},
+}
+
+func (t Theme) valid() bool {
+	for _, theme := range Themes {
+		if theme.Value == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (q RecordingQuality) valid() bool {
+	for _, quality := range RecordingQualities {
+		if quality.Value == q {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Bounds of the settings holding a duration
+const (
+	minWatcherInterval  = 50
+	maxWatcherInterval  = 10000
+	minAutosaveInterval = 5
+	maxAutosaveInterval = 3600
+)
+
+// User settings, grouped like the screens of the settings dialog
+type Settings struct {
+	General GeneralSettings `toml:"general" json:"general"`
+	Graph   GraphSettings   `toml:"graph" json:"graph"`
+	Media   MediaSettings   `toml:"media" json:"media"`
+	Game    GameSettings    `toml:"game" json:"game"`
+}
+
+type GeneralSettings struct {
+	Theme Theme `toml:"theme" json:"theme"`
+	// Time between two scans of the lab by the watcher, in milliseconds
+	WatcherInterval int `toml:"watcherinterval" json:"watcherInterval"`
+}
+
+type GraphSettings struct {
+	// Time between two automatic saves of the opened graph, in seconds. 0 disables autosave
+	AutosaveInterval int `toml:"autosaveinterval" json:"autosaveInterval"`
+	// Graph copied when creating a new graph, starting from the lab root. Empty for a blank graph
+	DefaultTemplate string `toml:"defaulttemplate" json:"defaultTemplate"`
+}
+
+type MediaSettings struct {
+	RecordingQuality RecordingQuality `toml:"recordingquality" json:"recordingQuality"`
+	// Directory where medias are saved, starting from the lab root. Empty to save them
+	// next to the graph they're added to
+	MediaFolder string `toml:"mediafolder" json:"mediaFolder"`
+}
+
+type GameSettings struct {
+	// Game selected when the lab is opened. 0 for none
+	DefaultGame int64 `toml:"defaultgame" json:"defaultGame"`
+}
+
+// A setting with an invalid value
+type FieldError struct {
+	// Path of the setting, like general.theme
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		General: GeneralSettings{
+			Theme:           SYSTEM,
+			WatcherInterval: 100,
+		},
+		Graph: GraphSettings{
+			AutosaveInterval: 30,
+		},
+		Media: MediaSettings{
+			RecordingQuality: HIGH,
+		},
+	}
+}
+
+// Returns every invalid setting. An empty list means the settings are valid
+func (s Settings) Validate() []FieldError {
+	return s.validate(false)
+}
+
+// Replaces every invalid setting by its default value and returns the errors found
+func (s *Settings) fix() []FieldError {
+	return s.validate(true)
+}
+
+func (s *Settings) validate(fix bool) []FieldError {
+	d := DefaultSettings()
+	errs := make([]FieldError, 0)
+	invalid := func(field, message string, reset func()) {
+		errs = append(errs, FieldError{field, message})
+		if fix {
+			reset()
+		}
+	}
+
+	if !s.General.Theme.valid() {
+		invalid("general.theme", fmt.Sprintf("unknown theme %q", s.General.Theme), func() {
+			s.General.Theme = d.General.Theme
+		})
+	}
+
+	if s.General.WatcherInterval < minWatcherInterval || s.General.WatcherInterval > maxWatcherInterval {
+		invalid("general.watcherInterval", fmt.Sprintf("must be between %d and %d milliseconds", minWatcherInterval, maxWatcherInterval), func() {
+			s.General.WatcherInterval = d.General.WatcherInterval
+		})
+	}
+
+	if s.Graph.AutosaveInterval != 0 && (s.Graph.AutosaveInterval < minAutosaveInterval || s.Graph.AutosaveInterval > maxAutosaveInterval) {
+		invalid("graph.autosaveInterval", fmt.Sprintf("must be 0 or between %d and %d seconds", minAutosaveInterval, maxAutosaveInterval), func() {
+			s.Graph.AutosaveInterval = d.Graph.AutosaveInterval
+		})
+	}
+
+	if msg := checkLabPath(s.Graph.DefaultTemplate); msg != "" {
+		invalid("graph.defaultTemplate", msg, func() {
+			s.Graph.DefaultTemplate = d.Graph.DefaultTemplate
+		})
+	} else if s.Graph.DefaultTemplate != "" && filepath.Ext(s.Graph.DefaultTemplate) != ".json" {
+		invalid("graph.defaultTemplate", "must be a graph", func() {
+			s.Graph.DefaultTemplate = d.Graph.DefaultTemplate
+		})
+	}
+
+	if !s.Media.RecordingQuality.valid() {
+		invalid("media.recordingQuality", fmt.Sprintf("unknown quality %q", s.Media.RecordingQuality), func() {
+			s.Media.RecordingQuality = d.Media.RecordingQuality
+		})
+	}
+
+	if msg := checkLabPath(s.Media.MediaFolder); msg != "" {
+		invalid("media.mediaFolder", msg, func() {
+			s.Media.MediaFolder = d.Media.MediaFolder
+		})
+	}
+
+	if s.Game.DefaultGame < 0 {
+		invalid("game.defaultGame", "unknown game", func() {
+			s.Game.DefaultGame = d.Game.DefaultGame
+		})
+	}
+
+	return errs
+}
+
+// Paths stored in the settings start from the lab root and must stay inside the lab
+func checkLabPath(p string) string {
+	if p == "" {
+		return ""
+	}
+
+	if filepath.IsAbs(p) || strings.HasPrefix(p, "/") {
+		return "must start from the lab root"
+	}
+
+	if c := path.Clean(filepath.ToSlash(p)); c == ".." || strings.HasPrefix(c, "../") {
+		return "must be inside the lab"
+	}
+
+	return ""
+}
+
+// Error returned when saving invalid settings
+type SettingsError struct {
+	Fields []FieldError
+}
+
+func (e *SettingsError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		fields = append(fields, f.Field+": "+f.Message)
+	}
+
+	return "invalid settings: " + strings.Join(fields, ", ")
+}
+
+// Returns the settings in effect: the ones set by the current lab's lab.toml come first,
+// then the ones of config.toml, then the default values
+func (ac *AppConfig) GetSettings() Settings {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	return ac.lab.apply(ac.ConfigFile.Settings)
+}
+
+func (ac *AppConfig) GetDefaultSettings() Settings {
+	return DefaultSettings()
+}
+
+// Returns the invalid settings found in config.toml and in the lab's lab.toml when they were
+// last read. They were replaced by the value they override
+func (ac *AppConfig) GetSettingsErrors() []FieldError {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	return append(slices.Clone(ac.settingsErrors), ac.labErrors...)
+}
+
+// Returns the invalid settings without saving anything, to check a form as the user fills it
+func (ac *AppConfig) ValidateSettings(s Settings) []FieldError {
+	return s.Validate()
+}
+
+// Saves the settings in config.toml if they're all valid. Otherwise returns a SettingsError
+// listing them. Settings set by the lab are saved with SaveLabSettings
+func (ac *AppConfig) SaveSettings(s Settings) error {
+	if errs := s.Validate(); len(errs) != 0 {
+		return &SettingsError{errs}
+	}
+
+	ac.mu.Lock()
+	before := ac.state()
+	ac.ConfigFile.Settings = s
+	ac.settingsErrors = nil
+	err := ac.saveConfigFile()
+	change := ac.changeSince(before)
+	ac.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return ac.publish(change)
+}
+
+// Checks config.toml and the lab's lab.toml every interval and reloads the settings and the
+// labs when one of them was edited outside of the app. The frontend is told with a
+// settingschanged event and subscribers with a Change. Editing the current lab in config.toml
+// has no effect since labs are opened with SwitchLab. Returns once ctx is done
+func (ac *AppConfig) WatchConfigFile(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := ac.reloadIfEdited()
+			if err != nil {
+				ac.Logger.Error(err.Error())
+			}
+
+			if changed && ac.Ctx != nil {
+				runtime.EventsEmit(ac.Ctx, "settingschanged", ac.GetSettings(), ac.GetSettingsErrors())
+			}
+		}
+	}
+}
+
+func (ac *AppConfig) reloadIfEdited() (bool, error) {
+	ac.mu.Lock()
+	before := ac.state()
+	ac.mu.Unlock()
+
+	globalChanged, globalErr := ac.reloadConfigFileIfEdited()
+	labChanged, labErr := ac.reloadLabSettingsIfEdited()
+
+	ac.mu.Lock()
+	change := ac.changeSince(before)
+	ac.mu.Unlock()
+
+	return globalChanged || labChanged, errors.Join(globalErr, labErr, ac.publish(change))
+}
+
+func (ac *AppConfig) reloadLabSettingsIfEdited() (bool, error) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	var modTime time.Time
+	info, err := os.Stat(labSettingsPath(ac.ConfigFile.LabPath))
+	if err == nil {
+		modTime = info.ModTime()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	if ac.ConfigFile.LabPath == "" || modTime.Equal(ac.labModTime) {
+		return false, nil
+	}
+
+	err = ac.loadLabSettings()
+	return err == nil, err
+}
+
+func (ac *AppConfig) reloadConfigFileIfEdited() (bool, error) {
+	info, err := os.Stat(ac.Paths.ConfigFile())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	ac.mu.Lock()
+	edited := !info.ModTime().Equal(ac.modTime)
+	ac.mu.Unlock()
+	if !edited {
+		return false, nil
+	}
+
+	cfg, settingsErrors, modTime, err := ac.readConfigFile()
+
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	// A file that can't be parsed is probably still being edited, it'll be read again once saved
+	ac.modTime = info.ModTime()
+	if err != nil {
+		return false, err
+	}
+
+	ac.modTime = modTime
+	ac.ConfigFile.Labs = cfg.Labs
+	ac.ConfigFile.Settings = cfg.Settings
+	ac.settingsErrors = settingsErrors
+	return true, nil
+}
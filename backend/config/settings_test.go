@@ -0,0 +1,160 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidateSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		edit   func(s *Settings)
+		fields []string
+	}{
+		{
+			name:   "default settings are valid",
+			edit:   func(s *Settings) {},
+			fields: []string{},
+		},
+		{
+			name:   "autosave can be disabled",
+			edit:   func(s *Settings) { s.Graph.AutosaveInterval = 0 },
+			fields: []string{},
+		},
+		{
+			name: "unknown values and out of range intervals",
+			edit: func(s *Settings) {
+				s.General.Theme = "PINK"
+				s.General.WatcherInterval = 1
+				s.Graph.AutosaveInterval = 2
+				s.Media.RecordingQuality = ""
+			},
+			fields: []string{"general.theme", "general.watcherInterval", "graph.autosaveInterval", "media.recordingQuality"},
+		},
+		{
+			name: "paths outside of the lab",
+			edit: func(s *Settings) {
+				s.Graph.DefaultTemplate = "Templates/combo.png"
+				s.Media.MediaFolder = "Médias/../../Bureau"
+			},
+			fields: []string{"graph.defaultTemplate", "media.mediaFolder"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultSettings()
+			tt.edit(&s)
+
+			errs := s.Validate()
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("got %v, want errors on %v", errs, tt.fields)
+			}
+
+			for i, f := range tt.fields {
+				if errs[i].Field != f {
+					t.Errorf("got %s, want %s", errs[i].Field, f)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveAndReloadSettings(t *testing.T) {
+	paths := createTempPaths(t)
+	os.MkdirAll(paths.ConfigDir, os.ModePerm)
+	ac := NewAppConfig(paths)
+
+	s := ac.GetSettings()
+	s.General.Theme = "PINK"
+	var settingsErr *SettingsError
+	if err := ac.SaveSettings(s); !errors.As(err, &settingsErr) {
+		t.Fatalf("got %v, want a SettingsError", err)
+	}
+
+	s.General.Theme = DARK
+	if err := ac.SaveSettings(s); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if changed, _ := ac.reloadIfEdited(); changed {
+		t.Errorf("the app's own save shouldn't be seen as an edit")
+	}
+
+	// Edited by hand: a missing setting and an invalid one
+	time.Sleep(10 * time.Millisecond)
+	err := os.WriteFile(paths.ConfigFile(), []byte("[settings.general]\ntheme = \"LIGHT\"\n\n[settings.media]\nrecordingquality = \"4K\"\n"), 0644)
+	if err != nil {
+		t.Fatalf("couldn't edit the config file: %v", err)
+	}
+
+	changed, err := ac.reloadIfEdited()
+	if err != nil || !changed {
+		t.Fatalf("the edit was not reloaded: %v", err)
+	}
+
+	got := ac.GetSettings()
+	if got.General.Theme != LIGHT || got.General.WatcherInterval != DefaultSettings().General.WatcherInterval {
+		t.Errorf("wrong general settings: %+v", got.General)
+	}
+
+	if got.Media.RecordingQuality != HIGH {
+		t.Errorf("an invalid setting should get its default value: %+v", got.Media)
+	}
+
+	if errs := ac.GetSettingsErrors(); len(errs) != 1 || errs[0].Field != "media.recordingQuality" {
+		t.Errorf("wrong settings errors: %v", errs)
+	}
+}
+
+func TestLabSettings(t *testing.T) {
+	paths := createTempPaths(t)
+	os.MkdirAll(paths.ConfigDir, os.ModePerm)
+	lab, err := os.MkdirTemp("", "testLabSettings")
+	if err != nil {
+		t.Fatalf("couldn't create lab: %v", err)
+	}
+	defer os.RemoveAll(lab)
+
+	os.MkdirAll(filepath.Join(lab, ".labmonster"), os.ModePerm)
+	err = os.WriteFile(labSettingsPath(lab), []byte("[media]\nmediafolder = \"Médias\"\n\n[graph]\ndefaulttemplate = \"/etc/passwd\"\n"), 0644)
+	if err != nil {
+		t.Fatalf("couldn't write lab.toml: %v", err)
+	}
+
+	ac := NewAppConfig(paths)
+	global := ac.GetGlobalSettings()
+	global.Media.MediaFolder = "Enregistrements"
+	global.Graph.DefaultTemplate = "Modèles/punish.json"
+	if err := ac.SaveSettings(global); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	ac.CreateAppConfig(lab)
+
+	got := ac.GetSettings()
+	if got.Media.MediaFolder != "Médias" {
+		t.Errorf("the lab's media folder should come first, got %s", got.Media.MediaFolder)
+	}
+
+	if got.Graph.DefaultTemplate != "Modèles/punish.json" {
+		t.Errorf("an invalid lab setting should fall back to the global one, got %s", got.Graph.DefaultTemplate)
+	}
+
+	if errs := ac.GetSettingsErrors(); len(errs) != 1 || errs[0].Field != "lab.graph.defaultTemplate" {
+		t.Errorf("wrong settings errors: %v", errs)
+	}
+
+	game := int64(2)
+	if err := ac.SaveLabSettings(LabSettings{Game: LabGameSettings{DefaultGame: &game}}); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	got = ac.GetSettings()
+	if got.Game.DefaultGame != 2 || got.Media.MediaFolder != "Enregistrements" {
+		t.Errorf("wrong settings after saving the lab's: %+v", got)
+	}
+}
@@ -2,34 +2,145 @@ package db
 
 import (
 	"database/sql"
+	"errors"
+	"flow-poc/backend/config"
 	repository "flow-poc/backend/db/repository"
-	"log"
+	"flow-poc/backend/filesystem/fsutil"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func createDbFile() {
-	_, err := os.Stat("core.db")
-	if err == nil || !os.IsNotExist(err) {
-		return
+var ErrNoDatabase = errors.New("no lab is opened so there is no database")
+
+// Connection to the database of the opened lab. Repositories keep the same Conn for the
+// whole life of the app and ask it for queries on each call, so switching labs only
+// requires opening another database
+type Conn struct {
+	// Where the database of each lab and the seed database are stored
+	paths config.Paths
+
+	mu sync.RWMutex
+	db *sql.DB
+	q  *repository.Queries
+	// Why the database of the current lab couldn't be opened, returned by Queries
+	openErr error
+}
+
+// Opens the database of the current lab, if any, and the one of each lab opened afterwards
+func NewConn(cfg *config.AppConfig) (*Conn, error) {
+	c := &Conn{paths: cfg.Paths}
+	cfg.Subscribe(func(change config.Change) error {
+		if !change.LabSwitched() || change.Lab == "" {
+			return nil
+		}
+		return c.Open(change.Lab)
+	})
+
+	labPath := cfg.LabPath()
+	if labPath == "" {
+		return c, nil
+	}
+
+	return c, c.Open(labPath)
+}
+
+// Closes the current database and opens the one of the given lab, then applies the migrations
+// it doesn't have yet. A database left inside the lab by older versions is moved to the data
+// directory first. A lab without database starts from a copy of the seed database, if any.
+// If the database can't be opened, queries return the error until another lab is opened
+func (c *Conn) Open(labPath string) error {
+	db, err := c.open(labPath)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.db != nil {
+		c.db.Close()
 	}
 
-	f, errCreate := os.Create("core.db")
-	if errCreate != nil {
-		log.Fatalf("Impossible de créer le fichier de base de donnée: %v\n", errCreate)
+	c.db = db
+	c.q = nil
+	c.openErr = nil
+	if err != nil {
+		c.openErr = fmt.Errorf("couldn't open the database of %s: %w", labPath, err)
+		return c.openErr
 	}
 
-	f.Close()
+	c.q = repository.New(db)
+	return nil
 }
 
-func ConnectToDb() *repository.Queries {
-	createDbFile()
+func (c *Conn) open(labPath string) (*sql.DB, error) {
+	err := c.paths.MigrateLabDatabase(labPath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := c.paths.LabDatabase(labPath)
+	err = os.MkdirAll(filepath.Dir(p), os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := c.paths.SeedDatabase()
+	if !fsutil.Exists(p) && fsutil.Exists(seed) {
+		err := fsutil.CopyFile(seed, p)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	db, err := sql.Open("sqlite3", "core.db")
+	// SQLite only enforces foreign keys, and so deletes in cascade, when asked on each connection
+	db, err := sql.Open("sqlite3", p+"?_foreign_keys=on")
 	if err != nil {
-		log.Fatalf("Impossible de se connecter à la base de donnée: %v\n", err)
+		return nil, err
+	}
+
+	// sql.Open doesn't create the file, the first connection does
+	err = db.Ping()
+	if err == nil {
+		err = Migrate(db)
+	}
+
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Returns the queries of the opened lab's database
+func (c *Conn) Queries() (*repository.Queries, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.openErr != nil {
+		return nil, c.openErr
+	}
+
+	if c.q == nil {
+		return nil, ErrNoDatabase
+	}
+
+	return c.q, nil
+}
+
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.db == nil {
+		return nil
 	}
 
-	return repository.New(db)
+	err := c.db.Close()
+	c.db = nil
+	c.q = nil
+	c.openErr = nil
+	return err
 }
@@ -0,0 +1,27 @@
+// This package holds the helpers shared by the tests of the repositories
+package dbtest
+
+import (
+	"flow-poc/backend/config"
+	"flow-poc/backend/db"
+	"testing"
+)
+
+// Opens the database of a new temporary lab, with every migration applied.
+// The connection is closed at the end of the test
+func OpenTemp(t testing.TB) *db.Conn {
+	t.Helper()
+
+	cfg := &config.AppConfig{
+		ConfigFile: config.ConfigFile{LabPath: t.TempDir()},
+		Paths:      config.Paths{DataDir: t.TempDir()},
+	}
+
+	conn, err := db.NewConn(cfg)
+	if err != nil {
+		t.Fatalf("couldn't open the lab's database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
@@ -0,0 +1,244 @@
+package db
+
+import (
+	"bufio"
+	"cmp"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// Replaced in tests to run other migrations than the ones of the app
+var migrationsFS fs.FS = embeddedMigrations
+
+const migrationsDir = "migrations"
+
+var (
+	ErrDatabaseTooNew      = errors.New("the database was migrated by a newer version of the app")
+	ErrUnknownMigration    = errors.New("the database has a migration this version of the app doesn't know")
+	ErrMigrationOutOfOrder = errors.New("a migration older than the database's version was never applied")
+	ErrBadMigrationName    = errors.New("migration files must be named <version>_<name>.sql")
+	ErrDuplicateMigration  = errors.New("two migrations have the same version")
+)
+
+// Error returned when a migration can't be read or applied
+type MigrationError struct {
+	Version int64
+	Name    string
+	Err     error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("migration %d (%s): %v", e.Version, e.Name, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// A SQL file of the migrations directory. Files follow the format of goose, which sqlc also
+// reads: the statements after "-- +goose Up" are applied, the ones after "-- +goose Down" are
+// never run since migrations only go forward
+type migration struct {
+	version int64
+	name    string
+	up      string
+}
+
+// Applies every migration the database doesn't have yet, each one in its own transaction.
+// Applied migrations are recorded in the schema_migrations table. Nothing is applied if
+// the database was migrated by a newer version of the app or if a migration would be applied
+// out of order, since both mean the schema isn't the one the queries were written for
+func Migrate(db *sql.DB) error {
+	migrations, err := readMigrations(migrationsFS)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+  version INTEGER PRIMARY KEY,
+  name TEXT NOT NULL,
+  applied_at TEXT NOT NULL
+)`)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+
+	pending, err := pendingMigrations(migrations, applied)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if err := apply(db, m); err != nil {
+			return &MigrationError{m.version, m.name, err}
+		}
+	}
+
+	return nil
+}
+
+// Returns the migrations to apply, oldest first, once the applied ones were checked
+func pendingMigrations(migrations []migration, applied []int64) ([]migration, error) {
+	known := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.version] = true
+	}
+
+	var latest int64
+	if len(migrations) != 0 {
+		latest = migrations[len(migrations)-1].version
+	}
+
+	var current int64
+	for _, v := range applied {
+		if v > latest {
+			return nil, fmt.Errorf("version %d is newer than %d: %w", v, latest, ErrDatabaseTooNew)
+		}
+
+		if !known[v] {
+			return nil, fmt.Errorf("version %d: %w", v, ErrUnknownMigration)
+		}
+
+		current = max(current, v)
+	}
+
+	pending := make([]migration, 0)
+	for _, m := range migrations {
+		if slices.Contains(applied, m.version) {
+			continue
+		}
+
+		if m.version < current {
+			return nil, &MigrationError{m.version, m.name, ErrMigrationOutOfOrder}
+		}
+
+		pending = append(pending, m)
+	}
+
+	return pending, nil
+}
+
+func appliedVersions(db *sql.DB) ([]int64, error) {
+	rows, err := db.Query("SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make([]int64, 0)
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+func apply(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.up); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO schema_migrations(version, name, applied_at) VALUES (?, ?, ?)",
+		m.version, m.name, time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Reads the migrations of fsys, sorted by version
+func readMigrations(fsys fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+
+		version, name, found := strings.Cut(strings.TrimSuffix(e.Name(), ".sql"), "_")
+		v, err := strconv.ParseInt(version, 10, 64)
+		if !found || err != nil || v <= 0 {
+			return nil, fmt.Errorf("%s: %w", e.Name(), ErrBadMigrationName)
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(migrationsDir, e.Name()))
+		if err != nil {
+			return nil, &MigrationError{v, name, err}
+		}
+
+		migrations = append(migrations, migration{v, name, upStatements(string(b))})
+	}
+
+	slices.SortFunc(migrations, func(a, b migration) int {
+		return cmp.Compare(a.version, b.version)
+	})
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, &MigrationError{migrations[i].version, migrations[i].name, ErrDuplicateMigration}
+		}
+	}
+
+	return migrations, nil
+}
+
+// Returns the statements of the Up section of a goose migration, without the annotations
+func upStatements(content string) string {
+	var b strings.Builder
+	up := false
+
+	s := bufio.NewScanner(strings.NewReader(content))
+	for s.Scan() {
+		line := s.Text()
+		annotation, isAnnotation := strings.CutPrefix(strings.TrimSpace(line), "-- +goose ")
+		if !isAnnotation {
+			if up {
+				b.WriteString(line)
+				b.WriteString("\n")
+			}
+			continue
+		}
+
+		switch strings.TrimSpace(annotation) {
+		case "Up":
+			up = true
+		case "Down":
+			up = false
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"flow-poc/backend/config"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+const gamesMigration = `-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE games (id INTEGER PRIMARY KEY, name text NOT NULL)
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE games
+-- +goose StatementEnd
+`
+
+func openTempDb(t testing.TB) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "core.db"))
+	if err != nil {
+		t.Fatalf("couldn't open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func useMigrations(t testing.TB, files map[string]string) {
+	t.Helper()
+
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys["migrations/"+name] = &fstest.MapFile{Data: []byte(content)}
+	}
+
+	previous := migrationsFS
+	migrationsFS = fsys
+	t.Cleanup(func() { migrationsFS = previous })
+}
+
+func assertVersions(t testing.TB, db *sql.DB, want ...int64) {
+	t.Helper()
+
+	got, err := appliedVersions(db)
+	if err != nil {
+		t.Fatalf("couldn't read the applied migrations: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got versions %v, want %v", got, want)
+	}
+
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got versions %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("applies the embedded migrations to a new database", func(t *testing.T) {
+		db := openTempDb(t)
+		if err := Migrate(db); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if _, err := db.Exec("INSERT INTO games(name, iconPath) VALUES ('Guilty Gear Strive', '')"); err != nil {
+			t.Errorf("the games table should exist: %v", err)
+		}
+
+		if err := Migrate(db); err != nil {
+			t.Errorf("migrating twice shouldn't fail: %v", err)
+		}
+	})
+
+	t.Run("only applies the up statements of new migrations", func(t *testing.T) {
+		useMigrations(t, map[string]string{"1_games.sql": gamesMigration})
+		db := openTempDb(t)
+		if err := Migrate(db); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		useMigrations(t, map[string]string{
+			"1_games.sql":      gamesMigration,
+			"2_characters.sql": "-- +goose Up\nCREATE TABLE characters (id INTEGER PRIMARY KEY);\n",
+		})
+		if err := Migrate(db); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertVersions(t, db, 1, 2)
+	})
+
+	t.Run("a failed migration is rolled back", func(t *testing.T) {
+		useMigrations(t, map[string]string{
+			"1_games.sql":  gamesMigration,
+			"2_broken.sql": "-- +goose Up\nCREATE TABLE moves (id INTEGER PRIMARY KEY);\nCREATE TABLE games (id INTEGER);\n",
+		})
+		db := openTempDb(t)
+
+		var migrationErr *MigrationError
+		if err := Migrate(db); !errors.As(err, &migrationErr) || migrationErr.Version != 2 {
+			t.Fatalf("got %v, want an error of migration 2", err)
+		}
+
+		assertVersions(t, db, 1)
+		if _, err := db.Exec("SELECT * FROM moves"); err == nil {
+			t.Errorf("the statements of the failed migration should have been rolled back")
+		}
+	})
+
+	t.Run("refuses a database migrated by a newer version", func(t *testing.T) {
+		useMigrations(t, map[string]string{
+			"1_games.sql":      gamesMigration,
+			"2_characters.sql": "-- +goose Up\nCREATE TABLE characters (id INTEGER PRIMARY KEY);\n",
+		})
+		db := openTempDb(t)
+		if err := Migrate(db); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		useMigrations(t, map[string]string{"1_games.sql": gamesMigration})
+		if err := Migrate(db); !errors.Is(err, ErrDatabaseTooNew) {
+			t.Errorf("got %v, want %v", err, ErrDatabaseTooNew)
+		}
+	})
+
+	t.Run("refuses to apply a migration out of order", func(t *testing.T) {
+		useMigrations(t, map[string]string{
+			"1_games.sql":      gamesMigration,
+			"3_characters.sql": "-- +goose Up\nCREATE TABLE characters (id INTEGER PRIMARY KEY);\n",
+		})
+		db := openTempDb(t)
+		if err := Migrate(db); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		useMigrations(t, map[string]string{
+			"1_games.sql":      gamesMigration,
+			"2_moves.sql":      "-- +goose Up\nCREATE TABLE moves (id INTEGER PRIMARY KEY);\n",
+			"3_characters.sql": "-- +goose Up\nCREATE TABLE characters (id INTEGER PRIMARY KEY);\n",
+		})
+		if err := Migrate(db); !errors.Is(err, ErrMigrationOutOfOrder) {
+			t.Errorf("got %v, want %v", err, ErrMigrationOutOfOrder)
+		}
+
+		assertVersions(t, db, 1, 3)
+	})
+
+	t.Run("refuses badly named migrations", func(t *testing.T) {
+		useMigrations(t, map[string]string{"games.sql": gamesMigration})
+		if err := Migrate(openTempDb(t)); !errors.Is(err, ErrBadMigrationName) {
+			t.Errorf("got %v, want %v", err, ErrBadMigrationName)
+		}
+	})
+}
+
+func TestOpen(t *testing.T) {
+	lab := t.TempDir()
+	c := &Conn{paths: config.Paths{DataDir: t.TempDir()}}
+
+	if _, err := c.Queries(); !errors.Is(err, ErrNoDatabase) {
+		t.Errorf("got %v, want %v", err, ErrNoDatabase)
+	}
+
+	if err := c.Open(lab); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Queries(); err != nil {
+		t.Errorf("got an error but didn't want one: %v", err)
+	}
+
+	useMigrations(t, map[string]string{"1_games.sql": gamesMigration})
+	if err := c.Open(lab); !errors.Is(err, ErrDatabaseTooNew) {
+		t.Fatalf("got %v, want %v", err, ErrDatabaseTooNew)
+	}
+
+	if _, err := c.Queries(); !errors.Is(err, ErrDatabaseTooNew) {
+		t.Errorf("queries should return why the database couldn't be opened, got %v", err)
+	}
+}
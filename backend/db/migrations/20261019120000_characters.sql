@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS characters (
+  id INTEGER PRIMARY KEY,
+  gameId INTEGER NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+  name text NOT NULL,
+  shortName text NOT NULL,
+  iconPath text NOT NULL,
+  archetype text NOT NULL,
+  health INTEGER NOT NULL,
+  forwardWalkSpeed REAL NOT NULL,
+  backWalkSpeed REAL NOT NULL,
+  UNIQUE (gameId, name)
+);
+CREATE INDEX IF NOT EXISTS characters_gameId ON characters(gameId);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX characters_gameId;
+DROP TABLE characters;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS moves (
+  id INTEGER PRIMARY KEY,
+  characterId INTEGER NOT NULL REFERENCES characters(id) ON DELETE CASCADE,
+  notation text NOT NULL,
+  name text NOT NULL,
+  startup INTEGER NOT NULL,
+  active INTEGER NOT NULL,
+  recovery INTEGER NOT NULL,
+  onBlock INTEGER NOT NULL,
+  onHit INTEGER NOT NULL,
+  damage INTEGER NOT NULL,
+  guard text NOT NULL CHECK (guard IN ('ALL', 'HIGH', 'LOW', 'UNBLOCKABLE')),
+  cancels text NOT NULL,
+  invulnerable text NOT NULL,
+  armor text NOT NULL,
+  UNIQUE (characterId, notation)
+);
+CREATE INDEX IF NOT EXISTS moves_characterId_onBlock ON moves(characterId, onBlock);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX moves_characterId_onBlock;
+DROP TABLE moves;
+-- +goose StatementEnd
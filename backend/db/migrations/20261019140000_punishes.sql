@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE moves ADD COLUMN reach INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS combos (
+  id INTEGER PRIMARY KEY,
+  characterId INTEGER NOT NULL REFERENCES characters(id) ON DELETE CASCADE,
+  starterId INTEGER NOT NULL REFERENCES moves(id) ON DELETE CASCADE,
+  name text NOT NULL,
+  notation text NOT NULL,
+  damage INTEGER NOT NULL,
+  cornerCarry INTEGER NOT NULL,
+  oki INTEGER NOT NULL,
+  meter INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS combos_characterId ON combos(characterId);
+
+CREATE TABLE IF NOT EXISTS punish_priorities (
+  gameId INTEGER PRIMARY KEY REFERENCES games(id) ON DELETE CASCADE,
+  damage REAL NOT NULL,
+  cornerCarry REAL NOT NULL,
+  oki REAL NOT NULL,
+  meter REAL NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE punish_priorities;
+DROP INDEX combos_characterId;
+DROP TABLE combos;
+ALTER TABLE moves DROP COLUMN reach;
+-- +goose StatementEnd
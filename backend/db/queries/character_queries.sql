@@ -0,0 +1,24 @@
+-- name: ListCharacters :many
+SELECT * FROM characters
+WHERE gameId = ?
+ORDER BY name;
+
+-- name: GetOneCharacter :one
+SELECT * FROM characters
+WHERE id = ? LIMIT 1;
+
+-- name: AddCharacter :one
+INSERT INTO characters(
+  gameId, name, shortName, iconPath, archetype, health, forwardWalkSpeed, backWalkSpeed
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: EditCharacter :exec
+UPDATE characters SET name = ?, shortName = ?, iconPath = ?, archetype = ?, health = ?, forwardWalkSpeed = ?, backWalkSpeed = ?
+WHERE id = ?;
+
+-- name: DeleteCharacter :exec
+DELETE FROM characters
+WHERE id = ?;
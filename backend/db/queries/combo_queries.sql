@@ -0,0 +1,24 @@
+-- name: ListCombos :many
+SELECT * FROM combos
+WHERE characterId = ?
+ORDER BY damage DESC, name;
+
+-- name: GetOneCombo :one
+SELECT * FROM combos
+WHERE id = ? LIMIT 1;
+
+-- name: AddCombo :one
+INSERT INTO combos(
+  characterId, starterId, name, notation, damage, cornerCarry, oki, meter
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: EditCombo :exec
+UPDATE combos SET starterId = ?, name = ?, notation = ?, damage = ?, cornerCarry = ?, oki = ?, meter = ?
+WHERE id = ?;
+
+-- name: DeleteCombo :exec
+DELETE FROM combos
+WHERE id = ?;
@@ -0,0 +1,34 @@
+-- name: ListMoves :many
+SELECT * FROM moves
+WHERE characterId = ?
+ORDER BY startup, notation;
+
+-- name: ListPunishableMoves :many
+SELECT * FROM moves
+WHERE characterId = ? AND onBlock <= ?
+ORDER BY onBlock, startup;
+
+-- name: ListMovesStartingWithin :many
+SELECT * FROM moves
+WHERE characterId = ? AND startup BETWEEN 1 AND ? AND (reach = 0 OR reach >= ?)
+ORDER BY startup, notation;
+
+-- name: GetOneMove :one
+SELECT * FROM moves
+WHERE id = ? LIMIT 1;
+
+-- name: AddMove :one
+INSERT INTO moves(
+  characterId, notation, name, startup, active, recovery, onBlock, onHit, damage, guard, cancels, invulnerable, armor, reach
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: EditMove :exec
+UPDATE moves SET notation = ?, name = ?, startup = ?, active = ?, recovery = ?, onBlock = ?, onHit = ?, damage = ?, guard = ?, cancels = ?, invulnerable = ?, armor = ?, reach = ?
+WHERE id = ?;
+
+-- name: DeleteMove :exec
+DELETE FROM moves
+WHERE id = ?;
@@ -0,0 +1,12 @@
+-- name: GetPunishPriorities :one
+SELECT * FROM punish_priorities
+WHERE gameId = ? LIMIT 1;
+
+-- name: SavePunishPriorities :exec
+INSERT INTO punish_priorities(
+  gameId, damage, cornerCarry, oki, meter
+) VALUES (
+  ?, ?, ?, ?, ?
+)
+ON CONFLICT (gameId) DO UPDATE SET
+  damage = excluded.damage, cornerCarry = excluded.cornerCarry, oki = excluded.oki, meter = excluded.meter;
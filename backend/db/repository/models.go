@@ -4,8 +4,58 @@
 
 package repository
 
+type Character struct {
+	ID               int64   `json:"id"`
+	Gameid           int64   `json:"gameid"`
+	Name             string  `json:"name"`
+	Shortname        string  `json:"shortname"`
+	Iconpath         string  `json:"iconpath"`
+	Archetype        string  `json:"archetype"`
+	Health           int64   `json:"health"`
+	Forwardwalkspeed float64 `json:"forwardwalkspeed"`
+	Backwalkspeed    float64 `json:"backwalkspeed"`
+}
+
+type Combo struct {
+	ID          int64  `json:"id"`
+	Characterid int64  `json:"characterid"`
+	Starterid   int64  `json:"starterid"`
+	Name        string `json:"name"`
+	Notation    string `json:"notation"`
+	Damage      int64  `json:"damage"`
+	Cornercarry int64  `json:"cornercarry"`
+	Oki         int64  `json:"oki"`
+	Meter       int64  `json:"meter"`
+}
+
 type Game struct {
 	ID       int64  `json:"id"`
 	Name     string `json:"name"`
 	Iconpath string `json:"iconpath"`
 }
+
+type Move struct {
+	ID           int64  `json:"id"`
+	Characterid  int64  `json:"characterid"`
+	Notation     string `json:"notation"`
+	Name         string `json:"name"`
+	Startup      int64  `json:"startup"`
+	Active       int64  `json:"active"`
+	Recovery     int64  `json:"recovery"`
+	Onblock      int64  `json:"onblock"`
+	Onhit        int64  `json:"onhit"`
+	Damage       int64  `json:"damage"`
+	Guard        string `json:"guard"`
+	Cancels      string `json:"cancels"`
+	Invulnerable string `json:"invulnerable"`
+	Armor        string `json:"armor"`
+	Reach        int64  `json:"reach"`
+}
+
+type PunishPriority struct {
+	Gameid      int64   `json:"gameid"`
+	Damage      float64 `json:"damage"`
+	Cornercarry float64 `json:"cornercarry"`
+	Oki         float64 `json:"oki"`
+	Meter       float64 `json:"meter"`
+}
@@ -0,0 +1,387 @@
+// This package runs file operations on several elements of the lab at once. Every operation
+// is validated before anything is touched, then executed step by step. If a step fails,
+// every completed step is undone so the lab is left as it was before the operation.
+package batch
+
+import (
+	"errors"
+	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/favorites"
+	"flow-poc/backend/filesystem/fsutil"
+	"flow-poc/backend/filesystem/node"
+	"flow-poc/backend/filesystem/recentfiles"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrEmptyBatch         = errors.New("no element selected")
+	ErrLabRoot            = errors.New("the lab root can't be part of a batch operation")
+	ErrOutsideLab         = errors.New("the path is outside of the lab")
+	ErrNestedSelection    = errors.New("an element and one of its parents are both selected")
+	ErrAlreadyInDest      = errors.New("the element is already in the destination directory")
+	ErrMoveIntoItself     = errors.New("a directory can't be moved into itself or one of its children")
+	ErrDestinationNotADir = errors.New("the destination is not a directory")
+	ErrNotExecuted        = errors.New("not executed because a previous step failed")
+	ErrRollbackFailed     = errors.New("some steps couldn't be undone")
+)
+
+// Replaced in tests to make a step fail
+var rename = os.Rename
+
+// Result of the operation on a single element
+type ItemResult struct {
+	// Path of the element starting from the lab root
+	Path string `json:"path"`
+	// New path of the element starting from the lab root, for moves and duplications
+	NewPath string `json:"newPath"`
+	// Empty if the operation succeeded on this element
+	Error string `json:"error"`
+	// The operation succeeded on this element but was undone because another one failed
+	RolledBack bool `json:"rolledBack"`
+}
+
+type BatchHandler struct {
+	Cfg       *config.AppConfig
+	recent    *recentfiles.RecentlyOpened
+	favorites *favorites.Favorites
+}
+
+func NewBatchHandler(cfg *config.AppConfig, recent *recentfiles.RecentlyOpened, fav *favorites.Favorites) *BatchHandler {
+	return &BatchHandler{
+		Cfg:       cfg,
+		recent:    recent,
+		favorites: fav,
+	}
+}
+
+func (bh *BatchHandler) GetLabPath() string {
+	return bh.Cfg.LabPath()
+}
+
+// A step of a batch operation. undo is called if a later step fails
+type step struct {
+	index int
+	undo  func() error
+}
+
+// Moves every given file and directory into the destination directory. Elements whose name is
+// already taken in the destination get a number appended to it. Sidecars follow their file.
+// Elements are copied then removed when the destination is on another file system
+func (bh *BatchHandler) MovePaths(pathsFromLabRoot []string, destDirFromLabRoot string) ([]ItemResult, error) {
+	items, err := bh.validate(pathsFromLabRoot)
+	if err != nil {
+		return nil, &BatchError{"move", err}
+	}
+
+	dest, err := bh.validateDestination(items, destDirFromLabRoot)
+	if err != nil {
+		return nil, &BatchError{"move", err}
+	}
+
+	results := newResults(pathsFromLabRoot)
+	steps := make([]step, 0, len(items))
+	for i, item := range items {
+		item := item
+		info, err := os.Stat(item)
+		if err != nil {
+			return bh.rollback(results, steps, i, err, "move")
+		}
+
+		np := fsutil.NonDuplicatePath(filepath.Join(dest, filepath.Base(item)), info.IsDir())
+		err = moveWithSidecars(item, np)
+		if err != nil {
+			return bh.rollback(results, steps, i, err, "move")
+		}
+
+		results[i].NewPath = bh.fromLabRoot(np)
+		steps = append(steps, step{i, func() error {
+			return moveWithSidecars(np, item)
+		}})
+	}
+
+	for _, r := range results {
+		bh.recent.ReconcilePaths(r.Path, r.NewPath)
+		bh.favorites.ReconcilePaths(r.Path, r.NewPath)
+	}
+
+	return results, nil
+}
+
+// Deletes every given file and directory along with their sidecars. Elements are first moved to
+// a staging directory inside .labmonster so they can be restored if one of them can't be deleted
+func (bh *BatchHandler) DeletePaths(pathsFromLabRoot []string) ([]ItemResult, error) {
+	items, err := bh.validate(pathsFromLabRoot)
+	if err != nil {
+		return nil, &BatchError{"delete", err}
+	}
+
+	staging := filepath.Join(bh.GetLabPath(), ".labmonster", "trash", time.Now().Format("20060102150405.000000000"))
+	err = os.MkdirAll(staging, os.ModePerm)
+	if err != nil {
+		return nil, &BatchError{"delete", err}
+	}
+
+	results := newResults(pathsFromLabRoot)
+	steps := make([]step, 0, len(items))
+	for i, item := range items {
+		item := item
+		staged := filepath.Join(staging, fmt.Sprint(i))
+		err := moveWithSidecars(item, staged)
+		if err != nil {
+			results, err = bh.rollback(results, steps, i, err, "delete")
+			// Elements that couldn't be restored are kept in the staging directory
+			if !errors.Is(err, ErrRollbackFailed) {
+				os.RemoveAll(staging)
+			}
+
+			return results, err
+		}
+
+		steps = append(steps, step{i, func() error {
+			return moveWithSidecars(staged, item)
+		}})
+	}
+
+	err = os.RemoveAll(staging)
+	if err != nil {
+		return results, &BatchError{"delete", err}
+	}
+
+	bh.recent.CheckIfRecentFileStillExists()
+	bh.favorites.CheckIfFavoritesStillExist()
+
+	return results, nil
+}
+
+// Duplicates every given file and directory next to the original. Directories are copied recursively
+// and files are copied with their sidecars
+func (bh *BatchHandler) DuplicatePaths(pathsFromLabRoot []string) ([]ItemResult, error) {
+	items, err := bh.validate(pathsFromLabRoot)
+	if err != nil {
+		return nil, &BatchError{"duplicate", err}
+	}
+
+	results := newResults(pathsFromLabRoot)
+	steps := make([]step, 0, len(items))
+	for i, item := range items {
+		info, err := os.Stat(item)
+		if err != nil {
+			return bh.rollback(results, steps, i, err, "duplicate")
+		}
+
+		np := fsutil.NonDuplicatePath(item, info.IsDir())
+		if info.IsDir() {
+			err = fsutil.CopyDir(item, np, nil)
+		} else {
+			err = copyWithSidecars(item, np)
+		}
+
+		if err != nil {
+			return bh.rollback(results, steps, i, err, "duplicate")
+		}
+
+		results[i].NewPath = bh.fromLabRoot(np)
+		steps = append(steps, step{i, func() error {
+			return errors.Join(os.RemoveAll(np), node.RemoveSidecars(np))
+		}})
+	}
+
+	return results, nil
+}
+
+// Moves an element and the sidecars of a file. If a sidecar can't be moved, what was already
+// moved is put back so the element and its sidecars are never split
+func moveWithSidecars(src, dst string) error {
+	sidecars := node.ExistingSidecars(src)
+	err := move(src, dst)
+	if err != nil {
+		return err
+	}
+
+	for i, s := range sidecars {
+		err := move(s.Path, dst+s.Ext)
+		if err == nil {
+			continue
+		}
+
+		undoErrs := []error{err}
+		for _, moved := range sidecars[:i] {
+			undoErrs = append(undoErrs, move(dst+moved.Ext, moved.Path))
+		}
+
+		return errors.Join(append(undoErrs, move(dst, src))...)
+	}
+
+	return nil
+}
+
+// Renames src to dst. If they're on different file systems, src is copied to dst then removed
+func move(src, dst string) error {
+	err := rename(src, dst)
+	if err == nil || !fsutil.IsCrossDevice(err) {
+		return err
+	}
+
+	return copyThenRemove(src, dst)
+}
+
+// Copies src to dst then gets rid of src. The source is first moved to a hidden sibling so it's
+// never left half deleted: if it can't be moved, the copy is removed
+func copyThenRemove(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		err = fsutil.CopyDir(src, dst, nil)
+	} else {
+		err = fsutil.CopyFile(src, dst)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	staged := fsutil.NonDuplicatePath(filepath.Join(filepath.Dir(src), "."+filepath.Base(src)+".moved"), info.IsDir())
+	err = rename(src, staged)
+	if err == nil {
+		// The move is complete even if the staged copy can't be removed
+		os.RemoveAll(staged)
+		return nil
+	}
+
+	if rmErr := os.RemoveAll(dst); rmErr != nil {
+		return errors.Join(err, rmErr)
+	}
+
+	return err
+}
+
+// Copies a file and its sidecars. Nothing is left at dst if one of the copies fails
+func copyWithSidecars(src, dst string) error {
+	err := fsutil.CopyFile(src, dst)
+	if err == nil {
+		err = node.CopySidecars(src, dst)
+	}
+
+	if err != nil {
+		os.Remove(dst)
+		node.RemoveSidecars(dst)
+		return err
+	}
+
+	return nil
+}
+
+// Undoes every completed step in reverse order and fills the results accordingly
+func (bh *BatchHandler) rollback(results []ItemResult, steps []step, failed int, err error, op string) ([]ItemResult, error) {
+	results[failed].Error = err.Error()
+	results[failed].NewPath = ""
+
+	var undoErrs []error
+	for i := len(steps) - 1; i >= 0; i-- {
+		s := steps[i]
+		if uErr := s.undo(); uErr != nil {
+			results[s.index].Error = uErr.Error()
+			undoErrs = append(undoErrs, uErr)
+			continue
+		}
+
+		results[s.index].RolledBack = true
+		results[s.index].NewPath = ""
+	}
+
+	for i := failed + 1; i < len(results); i++ {
+		results[i].Error = ErrNotExecuted.Error()
+	}
+
+	if len(undoErrs) > 0 {
+		return results, &BatchError{op, errors.Join(append([]error{err, ErrRollbackFailed}, undoErrs...)...)}
+	}
+
+	return results, &BatchError{op, err}
+}
+
+// Makes sure every path exists inside the lab and that no element is selected along with one
+// of its parents. Returns the absolute paths of the elements
+func (bh *BatchHandler) validate(pathsFromLabRoot []string) ([]string, error) {
+	if len(pathsFromLabRoot) == 0 {
+		return nil, ErrEmptyBatch
+	}
+
+	labPath := bh.GetLabPath()
+	items := make([]string, 0, len(pathsFromLabRoot))
+	for _, p := range pathsFromLabRoot {
+		abs := filepath.Join(labPath, p)
+		if abs == filepath.Clean(labPath) {
+			return nil, &ItemError{p, ErrLabRoot}
+		}
+
+		if !fsutil.IsInside(labPath, abs) {
+			return nil, &ItemError{p, ErrOutsideLab}
+		}
+
+		if _, err := os.Lstat(abs); err != nil {
+			return nil, &ItemError{p, err}
+		}
+
+		for _, other := range items {
+			if other == abs || fsutil.IsInside(other, abs) || fsutil.IsInside(abs, other) {
+				return nil, &ItemError{p, ErrNestedSelection}
+			}
+		}
+
+		items = append(items, abs)
+	}
+
+	return items, nil
+}
+
+// Makes sure the destination of a move is an existing directory that doesn't already contain
+// one of the elements and isn't inside one of them. Returns the absolute path of the destination
+func (bh *BatchHandler) validateDestination(items []string, destDirFromLabRoot string) (string, error) {
+	dest := filepath.Join(bh.GetLabPath(), destDirFromLabRoot)
+	info, err := os.Stat(dest)
+	if err != nil {
+		return "", err
+	}
+
+	if !info.IsDir() {
+		return "", ErrDestinationNotADir
+	}
+
+	for _, item := range items {
+		if filepath.Dir(item) == dest {
+			return "", &ItemError{bh.fromLabRoot(item), ErrAlreadyInDest}
+		}
+
+		if item == dest || fsutil.IsInside(item, dest) {
+			return "", &ItemError{bh.fromLabRoot(item), ErrMoveIntoItself}
+		}
+	}
+
+	return dest, nil
+}
+
+func (bh *BatchHandler) fromLabRoot(absPath string) string {
+	rel, err := filepath.Rel(bh.GetLabPath(), absPath)
+	if err != nil {
+		return absPath
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+func newResults(pathsFromLabRoot []string) []ItemResult {
+	results := make([]ItemResult, 0, len(pathsFromLabRoot))
+	for _, p := range pathsFromLabRoot {
+		results = append(results, ItemResult{Path: strings.TrimPrefix(filepath.ToSlash(p), "/")})
+	}
+
+	return results
+}
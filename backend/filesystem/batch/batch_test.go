@@ -0,0 +1,297 @@
+package batch
+
+import (
+	"errors"
+	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/favorites"
+	"flow-poc/backend/filesystem/recentfiles"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// Creates a temporary lab holding the given files. Paths ending with a slash are created as directories
+func createTempLab(t testing.TB, paths ...string) (string, *BatchHandler) {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "testBatch")
+	if err != nil {
+		t.Fatalf("an error occured while creating temporary directory: %v", err)
+	}
+
+	for _, p := range paths {
+		abs := filepath.Join(dir, p)
+		if p[len(p)-1] == '/' {
+			if err := os.MkdirAll(abs, os.ModePerm); err != nil {
+				t.Fatalf("couldn't create directory: %v", err)
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(abs), os.ModePerm); err != nil {
+			t.Fatalf("couldn't create directory: %v", err)
+		}
+
+		if err := os.WriteFile(abs, []byte(p), 0644); err != nil {
+			t.Fatalf("couldn't create file: %v", err)
+		}
+	}
+
+	cfg := &config.AppConfig{
+		ConfigFile: config.ConfigFile{
+			LabPath: dir,
+		},
+	}
+
+	return dir, NewBatchHandler(cfg, recentfiles.NewRecentlyOpened(cfg, 10), favorites.NewFavorites(cfg))
+}
+
+// Makes the nth call to rename fail until the end of the test
+func failRenameAt(t testing.TB, n int) {
+	t.Helper()
+
+	calls := 0
+	rename = func(oldpath, newpath string) error {
+		calls++
+		if calls == n {
+			return errors.New("injected failure")
+		}
+		return os.Rename(oldpath, newpath)
+	}
+
+	t.Cleanup(func() {
+		rename = os.Rename
+	})
+}
+
+// Makes every rename into destDir fail as if it was on another file system until the end of the test
+func simulateCrossDevice(t testing.TB, destDir string) {
+	t.Helper()
+
+	rename = func(oldpath, newpath string) error {
+		if filepath.Dir(newpath) == destDir {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+		}
+		return os.Rename(oldpath, newpath)
+	}
+
+	t.Cleanup(func() {
+		rename = os.Rename
+	})
+}
+
+func assertExistence(t testing.TB, dir string, paths ...string) {
+	t.Helper()
+
+	for _, p := range paths {
+		if _, err := os.Stat(filepath.Join(dir, p)); err != nil {
+			t.Errorf("%s should exist: %v", p, err)
+		}
+	}
+}
+
+func assertAbsence(t testing.TB, dir string, paths ...string) {
+	t.Helper()
+
+	for _, p := range paths {
+		if _, err := os.Stat(filepath.Join(dir, p)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s shouldn't exist: %v", p, err)
+		}
+	}
+}
+
+func TestMovePaths(t *testing.T) {
+	t.Run("move several elements and rename the ones already taken", func(t *testing.T) {
+		dir, bh := createTempLab(t, "a.json", "Sol/a.json", "Ky/combo.json", "dest/a.json")
+		defer os.RemoveAll(dir)
+
+		results, err := bh.MovePaths([]string{"a.json", "Sol/a.json", "Ky"}, "dest")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		want := []string{"dest/a 1.json", "dest/a 2.json", "dest/Ky"}
+		for i, r := range results {
+			if r.NewPath != want[i] {
+				t.Errorf("got %s, want %s", r.NewPath, want[i])
+			}
+		}
+
+		assertExistence(t, dir, "dest/a.json", "dest/a 1.json", "dest/a 2.json", "dest/Ky/combo.json")
+		assertAbsence(t, dir, "a.json", "Sol/a.json", "Ky")
+	})
+
+	t.Run("every move is undone when one fails", func(t *testing.T) {
+		dir, bh := createTempLab(t, "a.json", "b.json", "c.json", "dest/")
+		defer os.RemoveAll(dir)
+		failRenameAt(t, 2)
+
+		results, err := bh.MovePaths([]string{"a.json", "b.json", "c.json"}, "dest")
+		if err == nil {
+			t.Fatal("wanted an error but didn't get one")
+		}
+
+		if !results[0].RolledBack || results[1].Error == "" || results[2].Error != ErrNotExecuted.Error() {
+			t.Errorf("wrong results: %+v", results)
+		}
+
+		assertExistence(t, dir, "a.json", "b.json", "c.json")
+		assertAbsence(t, dir, "dest/a.json")
+	})
+
+	t.Run("sidecars follow their file", func(t *testing.T) {
+		dir, bh := createTempLab(t, "setup.png", "setup.png.annotations", "setup.png.tags", "dest/")
+		defer os.RemoveAll(dir)
+
+		_, err := bh.MovePaths([]string{"setup.png"}, "dest")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertExistence(t, dir, "dest/setup.png", "dest/setup.png.annotations", "dest/setup.png.tags")
+		assertAbsence(t, dir, "setup.png.annotations", "setup.png.tags")
+	})
+
+	t.Run("a file and its sidecars are put back together when a sidecar can't be moved", func(t *testing.T) {
+		dir, bh := createTempLab(t, "a.json", "setup.png", "setup.png.annotations", "setup.png.tags", "dest/")
+		defer os.RemoveAll(dir)
+		// a.json, setup.png, then setup.png.annotations succeed and setup.png.tags fails
+		failRenameAt(t, 4)
+
+		_, err := bh.MovePaths([]string{"a.json", "setup.png"}, "dest")
+		if err == nil {
+			t.Fatal("wanted an error but didn't get one")
+		}
+
+		if errors.Is(err, ErrRollbackFailed) {
+			t.Errorf("the rollback should have succeeded: %v", err)
+		}
+
+		assertExistence(t, dir, "a.json", "setup.png", "setup.png.annotations", "setup.png.tags")
+		assertAbsence(t, dir, "dest/a.json", "dest/setup.png", "dest/setup.png.annotations")
+	})
+
+	t.Run("elements are copied then removed across file systems", func(t *testing.T) {
+		dir, bh := createTempLab(t, "setup.png", "setup.png.tags", "Ky/combo.json", "dest/")
+		defer os.RemoveAll(dir)
+		simulateCrossDevice(t, filepath.Join(dir, "dest"))
+
+		_, err := bh.MovePaths([]string{"setup.png", "Ky"}, "dest")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertExistence(t, dir, "dest/setup.png", "dest/setup.png.tags", "dest/Ky/combo.json")
+		assertAbsence(t, dir, "setup.png", "setup.png.tags", "Ky", ".setup.png.moved", ".Ky.moved")
+	})
+
+	t.Run("refuse invalid selections before touching anything", func(t *testing.T) {
+		dir, bh := createTempLab(t, "Sol/a.json", "Sol/Combos/", "dest/")
+		defer os.RemoveAll(dir)
+
+		cases := []struct {
+			paths []string
+			dest  string
+			want  error
+		}{
+			{[]string{}, "dest", ErrEmptyBatch},
+			{[]string{"Sol", "Sol/a.json"}, "dest", ErrNestedSelection},
+			{[]string{"Sol"}, "Sol/Combos", ErrMoveIntoItself},
+			{[]string{"Sol/a.json"}, "Sol", ErrAlreadyInDest},
+			{[]string{"Sol/a.json"}, "Sol/a.json", ErrDestinationNotADir},
+			{[]string{"../outside"}, "dest", ErrOutsideLab},
+			{[]string{""}, "dest", ErrLabRoot},
+		}
+
+		for _, c := range cases {
+			_, err := bh.MovePaths(c.paths, c.dest)
+			if !errors.Is(err, c.want) {
+				t.Errorf("moving %v into %s: got %v, want %v", c.paths, c.dest, err, c.want)
+			}
+		}
+
+		assertExistence(t, dir, "Sol/a.json", "Sol/Combos")
+	})
+}
+
+func TestDeletePaths(t *testing.T) {
+	t.Run("delete several elements", func(t *testing.T) {
+		dir, bh := createTempLab(t, "a.json", "Sol/combo.json")
+		defer os.RemoveAll(dir)
+
+		_, err := bh.DeletePaths([]string{"a.json", "Sol"})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertAbsence(t, dir, "a.json", "Sol")
+
+		entries, _ := os.ReadDir(filepath.Join(dir, ".labmonster", "trash"))
+		if len(entries) != 0 {
+			t.Errorf("the staging directory was not removed: %v", entries)
+		}
+	})
+
+	t.Run("deleted elements are restored when one fails", func(t *testing.T) {
+		dir, bh := createTempLab(t, "a.json", "Sol/combo.json", "b.json")
+		defer os.RemoveAll(dir)
+		failRenameAt(t, 3)
+
+		results, err := bh.DeletePaths([]string{"a.json", "Sol", "b.json"})
+		if err == nil {
+			t.Fatal("wanted an error but didn't get one")
+		}
+
+		if errors.Is(err, ErrRollbackFailed) {
+			t.Errorf("the rollback should have succeeded: %v", err)
+		}
+
+		if !results[0].RolledBack || !results[1].RolledBack {
+			t.Errorf("wrong results: %+v", results)
+		}
+
+		assertExistence(t, dir, "a.json", "Sol/combo.json", "b.json")
+	})
+
+	t.Run("sidecars are deleted and restored with their file", func(t *testing.T) {
+		dir, bh := createTempLab(t, "setup.png", "setup.png.annotations", "b.json")
+		defer os.RemoveAll(dir)
+		failRenameAt(t, 3)
+
+		_, err := bh.DeletePaths([]string{"setup.png", "b.json"})
+		if err == nil {
+			t.Fatal("wanted an error but didn't get one")
+		}
+		assertExistence(t, dir, "setup.png", "setup.png.annotations", "b.json")
+
+		rename = os.Rename
+		_, err = bh.DeletePaths([]string{"setup.png"})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+		assertAbsence(t, dir, "setup.png", "setup.png.annotations")
+	})
+}
+
+func TestDuplicatePaths(t *testing.T) {
+	dir, bh := createTempLab(t, "a.json", "Sol/combo.json", "Sol/Combos/bnb.json", "setup.png", "setup.png.annotations")
+	defer os.RemoveAll(dir)
+
+	results, err := bh.DuplicatePaths([]string{"a.json", "Sol", "setup.png"})
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if results[0].NewPath != "a 1.json" || results[1].NewPath != "Sol 1" {
+		t.Errorf("wrong new paths: %+v", results)
+	}
+
+	assertExistence(t, dir, "a.json", "a 1.json", "Sol/combo.json", "Sol 1/combo.json", "Sol 1/Combos/bnb.json")
+	assertExistence(t, dir, "setup.png.annotations", "setup 1.png.annotations")
+
+	b, err := os.ReadFile(filepath.Join(dir, "Sol 1", "combo.json"))
+	if err != nil || string(b) != "Sol/combo.json" {
+		t.Errorf("the content was not copied: %q, %v", b, err)
+	}
+}
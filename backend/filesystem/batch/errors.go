@@ -0,0 +1,29 @@
+package batch
+
+import "fmt"
+
+type BatchError struct {
+	op  string
+	err error
+}
+
+func (b *BatchError) Error() string {
+	return fmt.Sprintf("batch %s failed: %v", b.op, b.err)
+}
+
+func (b *BatchError) Unwrap() error {
+	return b.err
+}
+
+type ItemError struct {
+	path string
+	err  error
+}
+
+func (i *ItemError) Error() string {
+	return fmt.Sprintf("%s: %v", i.path, i.err)
+}
+
+func (i *ItemError) Unwrap() error {
+	return i.err
+}
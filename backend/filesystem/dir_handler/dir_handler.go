@@ -1,36 +1,53 @@
 package dirhandler
 
 import (
+	"context"
 	"errors"
 	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/favorites"
+	"flow-poc/backend/filesystem/fsutil"
+	"flow-poc/backend/filesystem/labignore"
 	"flow-poc/backend/filesystem/node"
 	"flow-poc/backend/filesystem/recentfiles"
-	"io"
+	"flow-poc/backend/jobs"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-var ErrMoveParentIntoChild = errors.New("impossible de déplacer un dossier parent dans un de ses enfants")
+var (
+	ErrMoveParentIntoChild = errors.New("impossible de déplacer un dossier parent dans un de ses enfants")
+	ErrNotADirectory       = errors.New("the path is not a directory")
+	ErrDuplicateLabRoot    = errors.New("the lab root can't be duplicated")
+	ErrDeleteLabRoot       = errors.New("the lab root can't be deleted")
+	ErrDestinationExists   = errors.New("a directory with the same name already exists in the destination")
+)
+
+// Replaced in tests to simulate a move between two file systems
+var rename = os.Rename
 
 type DirHandler struct {
 	Cfg         *config.AppConfig
 	Directories []string `json:"directories"`
 	recent      *recentfiles.RecentlyOpened
+	favorites   *favorites.Favorites
+	jobs        *jobs.Manager
 }
 
-func NewDirHandler(cfg *config.AppConfig, recent *recentfiles.RecentlyOpened) *DirHandler {
+func NewDirHandler(cfg *config.AppConfig, recent *recentfiles.RecentlyOpened, fav *favorites.Favorites, jm *jobs.Manager) *DirHandler {
 	dh := &DirHandler{
-		Cfg:    cfg,
-		recent: recent,
+		Cfg:       cfg,
+		recent:    recent,
+		favorites: fav,
+		jobs:      jm,
 	}
 
 	return dh
 }
 
 func (dh *DirHandler) GetLabPath() string {
-	return dh.Cfg.ConfigFile.LabPath
+	return dh.Cfg.LabPath()
 }
 
 // Get every directory name inside the lab and set the Directories
@@ -39,12 +56,18 @@ func (dh *DirHandler) GetLabDirs() error {
 	dh.Directories = make([]string, 0)
 
 	dh.Directories = append(dh.Directories, "/")
-	err := filepath.WalkDir(dh.GetLabPath(), func(path string, d fs.DirEntry, err error) error {
+
+	ignore, err := labignore.ForLab(dh.GetLabPath())
+	if err != nil {
+		return &GetLabDirsError{err}
+	}
+
+	err = filepath.WalkDir(dh.GetLabPath(), func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if d.Name() == ".labmonster" {
+		if d.IsDir() && ignore.Ignores(path, true) {
 			return filepath.SkipDir
 		}
 
@@ -73,7 +96,7 @@ func (dh *DirHandler) CreateDirectory(pathFromLabRoot string) (node.Node, error)
 	p := filepath.Join(dh.GetLabPath(), pathFromLabRoot)
 
 	if !doesDirExists(p) {
-		err := os.Mkdir(p, os.ModeDir)
+		err := os.Mkdir(p, os.ModePerm)
 		if err != nil {
 			return node.Node{}, err
 		}
@@ -101,10 +124,75 @@ func (dh *DirHandler) DeleteDirectory(pathFromLabRoot string) error {
 	}
 
 	dh.recent.CheckIfRecentFileStillExists()
+	dh.favorites.CheckIfFavoritesStillExist()
 
 	return nil
 }
 
+// Same as DeleteDirectory but runs as a job reporting every removed file. The directory is
+// first moved to .labmonster so it disappears from the lab right away. The job can only be
+// cancelled before that: once files start being removed, the deletion goes on until the end
+// since a half deleted directory couldn't be restored. Returns the job's id
+func (dh *DirHandler) StartDeleteDirectory(pathFromLabRoot string) string {
+	return dh.jobs.Start("delete", filepath.ToSlash(pathFromLabRoot), func(ctx context.Context, r *jobs.Reporter) (any, error) {
+		p := filepath.Join(dh.GetLabPath(), pathFromLabRoot)
+		if p == filepath.Clean(dh.GetLabPath()) {
+			return nil, &DeleteDirError{pathFromLabRoot, ErrDeleteLabRoot}
+		}
+
+		size, items, err := fsutil.Size(p)
+		if err != nil {
+			return nil, &DeleteDirError{pathFromLabRoot, err}
+		}
+		r.SetTotal(size, items)
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		staging := filepath.Join(dh.GetLabPath(), ".labmonster", "trash")
+		err = os.MkdirAll(staging, os.ModePerm)
+		if err != nil {
+			return nil, &DeleteDirError{pathFromLabRoot, err}
+		}
+
+		staged := fsutil.NonDuplicatePath(filepath.Join(staging, filepath.Base(p)), true)
+		err = rename(p, staged)
+		if err != nil {
+			return nil, &DeleteDirError{pathFromLabRoot, err}
+		}
+		dh.recent.CheckIfRecentFileStillExists()
+		dh.favorites.CheckIfFavoritesStillExist()
+
+		err = filepath.WalkDir(staged, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+
+			// Progress is reported with the path the file had in the lab
+			current := filepath.Join(p, strings.TrimPrefix(path, staged))
+			r.AddBytes(info.Size(), current)
+			r.ItemDone(current)
+			return nil
+		})
+
+		if err != nil {
+			return nil, &DeleteDirError{pathFromLabRoot, err}
+		}
+
+		return nil, os.RemoveAll(staged)
+	})
+}
+
 func (dh *DirHandler) RenameDirectory(oldPathFromRoot, newPathFromRoot string) error {
 	labPath := dh.GetLabPath()
 	p := filepath.Join(labPath, oldPathFromRoot)
@@ -112,7 +200,13 @@ func (dh *DirHandler) RenameDirectory(oldPathFromRoot, newPathFromRoot string) e
 
 	dh.recent.ReconcilePaths(oldPathFromRoot, newPathFromRoot)
 
-	return os.Rename(p, np)
+	err := os.Rename(p, np)
+	if err != nil {
+		return err
+	}
+
+	dh.favorites.ReconcilePaths(oldPathFromRoot, newPathFromRoot)
+	return nil
 }
 
 func doesDirExists(path string) bool {
@@ -120,85 +214,85 @@ func doesDirExists(path string) bool {
 	return !os.IsNotExist(err)
 }
 
-// Prevent the user to move a parent folder into one of its subfolders
+// Moves a directory inside another one. A simple rename is used when both are on the same
+// file system. Otherwise the directory is copied, keeping permissions and modification times,
+// and the original is removed once the copy is complete. If anything fails, the lab is left
+// as it was before the move
 func (dh *DirHandler) MoveDir(oldPathFromRoot, newPathFromRoot string) error {
+	return dh.moveDir(context.Background(), oldPathFromRoot, newPathFromRoot, nil)
+}
+
+// Same as MoveDir but runs as a cancellable job. Cancelling a move between two file systems
+// removes what was already copied and leaves the directory where it was. Returns the job's id
+func (dh *DirHandler) StartMoveDir(oldPathFromRoot, newPathFromRoot string) string {
+	return dh.jobs.Start("move", filepath.ToSlash(oldPathFromRoot), func(ctx context.Context, r *jobs.Reporter) (any, error) {
+		size, items, err := fsutil.Size(filepath.Join(dh.GetLabPath(), oldPathFromRoot))
+		if err != nil {
+			return nil, &MoveDirError{oldPathFromRoot, err}
+		}
+		r.SetTotal(size, items)
+
+		return nil, dh.moveDir(ctx, oldPathFromRoot, newPathFromRoot, r)
+	})
+}
+
+func (dh *DirHandler) moveDir(ctx context.Context, oldPathFromRoot, newPathFromRoot string, progress fsutil.Progress) error {
 	labPath := dh.GetLabPath()
 
 	p := filepath.Join(labPath, oldPathFromRoot)
 	np := filepath.Join(labPath, newPathFromRoot)
 	dirName := filepath.Base(p)
+	target := filepath.Join(np, dirName)
 
 	// You cannot move a parent folder into one of its subfolders
-	r, relErr := filepath.Rel(p, np)
-	if relErr != nil {
-		// filepath.Rel's error is nil if the paths are relatives so we leave if that's the case
-		return relErr
-	}
-
-	if !strings.Contains(r, "..") {
+	if p == np || fsutil.IsInside(p, np) {
 		return ErrMoveParentIntoChild
 	}
 
-	err := filepath.WalkDir(p, func(path string, file fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		rel, relErr := filepath.Rel(p, path)
-		if relErr != nil {
-			return relErr
-		}
-
-		var newPath string
-
-		if rel == "." {
-			newPath = filepath.Join(np, dirName)
-			mkErr := os.Mkdir(newPath, fs.ModeDir)
-			if mkErr != nil {
-				return mkErr
-			}
-
-			return nil
-		}
-
-		newPath = filepath.Join(np, dirName, rel)
-
-		if file.IsDir() {
-			mkErr := os.Mkdir(newPath, fs.ModeDir)
-			if mkErr != nil {
-				return mkErr
-			}
-
-			return nil
-		}
+	if fsutil.Exists(target) {
+		return &MoveDirError{oldPathFromRoot, ErrDestinationExists}
+	}
 
-		newFile, fErr := os.Create(newPath)
-		if fErr != nil {
-			return fErr
-		}
-		defer newFile.Close()
+	err := rename(p, target)
+	if err != nil && !fsutil.IsCrossDevice(err) {
+		return &MoveDirError{oldPathFromRoot, err}
+	}
 
-		oldFile, Oerr := os.Open(path)
-		if Oerr != nil {
-			return Oerr
+	if err != nil {
+		err = dh.copyThenRemove(ctx, p, target, progress)
+		if err != nil {
+			return &MoveDirError{oldPathFromRoot, err}
 		}
-		defer oldFile.Close()
+	}
 
-		_, cErr := io.Copy(newFile, oldFile)
-		if cErr != nil {
-			return cErr
-		}
+	oldPath := strings.TrimPrefix(filepath.ToSlash(oldPathFromRoot), "/")
+	newPath := strings.TrimPrefix(filepath.ToSlash(filepath.Join(newPathFromRoot, dirName)), "/")
+	dh.recent.ReconcilePaths(oldPath, newPath)
+	dh.favorites.ReconcilePaths(oldPath, newPath)
 
-		return nil
-	})
+	return nil
+}
 
+// Copies src to dst then gets rid of src. The source is first moved to a hidden sibling so it's
+// never left half deleted: if it can't be moved, the copy is removed. The sibling is on the
+// source's file system, so this rename works when the source isn't on the lab's file system
+func (dh *DirHandler) copyThenRemove(ctx context.Context, src, dst string, progress fsutil.Progress) error {
+	err := fsutil.CopyDirContext(ctx, src, dst, nil, progress)
 	if err != nil {
 		return err
 	}
 
-	rAllErr := os.RemoveAll(p)
-	if rAllErr != nil {
-		return rAllErr
+	staged := fsutil.NonDuplicatePath(filepath.Join(filepath.Dir(src), "."+filepath.Base(src)+".moved"), true)
+	err = rename(src, staged)
+	if err == nil {
+		// The move is complete even if the staged copy can't be removed
+		os.RemoveAll(staged)
+		return nil
 	}
-	return nil
+
+	if rmErr := os.RemoveAll(dst); rmErr != nil {
+		return errors.Join(err, rmErr)
+	}
+
+	return err
 }
@@ -1,14 +1,21 @@
 package dirhandler
 
 import (
+	"encoding/json"
+	"errors"
 	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/favorites"
 	"flow-poc/backend/filesystem/node"
 	"flow-poc/backend/filesystem/recentfiles"
+	"flow-poc/backend/graph"
+	"flow-poc/backend/jobs"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 )
 
 // Creates dir by joining the last 2 args with filepath.Join. The first
@@ -46,7 +53,7 @@ func createTempDir(t testing.TB, testDirName string) (string, *DirHandler) {
 			LabPath: dir,
 		},
 	}
-	dh := NewDirHandler(c, recentfiles.NewRecentlyOpened(c, 5))
+	dh := NewDirHandler(c, recentfiles.NewRecentlyOpened(c, 5), favorites.NewFavorites(c), jobs.NewManager(c))
 
 	return dir, dh
 }
@@ -277,3 +284,184 @@ func TestMoveDirectory(t *testing.T) {
 		}
 	})
 }
+
+func TestDuplicateDirectory(t *testing.T) {
+	t.Run("duplicate a directory and its content", func(t *testing.T) {
+		dir, dh := createTempDir(t, "testDuplicate")
+		defer os.RemoveAll(dir)
+		srcDir := createDirHelper(t, dir, "Sol")
+		createDirHelper(t, srcDir, "Combos")
+		createDirHelper(t, dir, "Sol 1")
+
+		err := os.WriteFile(filepath.Join(srcDir, "Combos", "bnb.json"), []byte("{}"), 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		n, err := dh.DuplicateDirectory("Sol", false)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if n.Name != "Sol 2" {
+			t.Errorf("wrong name, got %s, want %s", n.Name, "Sol 2")
+		}
+
+		assertFileExistence(t, filepath.Join(dir, "Sol 2", "Combos", "bnb.json"))
+		assertFileExistence(t, filepath.Join(srcDir, "Combos", "bnb.json"))
+	})
+
+	t.Run("references to medias inside the directory point to the copies", func(t *testing.T) {
+		dir, dh := createTempDir(t, "testDuplicateRefs")
+		defer os.RemoveAll(dir)
+		srcDir := createDirHelper(t, dir, "Sol")
+
+		g := graph.GetInitGraph()
+		g.Nodes[0].Data.Image = "Sol/setup.png"
+		g.Nodes[0].Data.Text = "voir Sol/punish.webm et other.png, pas Médias/Sol/oki.png ni Solo/bnb.png"
+		g.Edges = []graph.GraphEdge{{Id: "e", Label: "(Sol/oki.webm)"}}
+		b, _ := json.Marshal(g)
+		err := os.WriteFile(filepath.Join(srcDir, "notes.json"), b, 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		_, err = dh.DuplicateDirectory("Sol", true)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		b, err = os.ReadFile(filepath.Join(dir, "Sol 1", "notes.json"))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var copied graph.Graph
+		if err := json.Unmarshal(b, &copied); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if copied.Nodes[0].Data.Image != "Sol 1/setup.png" {
+			t.Errorf("image was not rewritten, got %s", copied.Nodes[0].Data.Image)
+		}
+
+		if copied.Nodes[0].Data.Text != "voir Sol 1/punish.webm et other.png, pas Médias/Sol/oki.png ni Solo/bnb.png" {
+			t.Errorf("text was not rewritten, got %s", copied.Nodes[0].Data.Text)
+		}
+
+		if copied.Edges[0].Label != "(Sol 1/oki.webm)" {
+			t.Errorf("edge label was not rewritten, got %s", copied.Edges[0].Label)
+		}
+	})
+
+	t.Run("a file can't be duplicated as a directory", func(t *testing.T) {
+		dir, dh := createTempDir(t, "testDuplicateFile")
+		defer os.RemoveAll(dir)
+		err := os.WriteFile(filepath.Join(dir, "test.json"), []byte("{}"), 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		_, err = dh.DuplicateDirectory("test.json", false)
+		if !errors.Is(err, ErrNotADirectory) {
+			t.Errorf("got %v, want %v", err, ErrNotADirectory)
+		}
+	})
+}
+
+// Makes every rename of srcDir out of its parent directory fail as if the destination was on
+// another file system. If failStaging is true, moving the source next to itself fails too
+func simulateCrossDevice(t testing.TB, srcDir string, failStaging bool) {
+	t.Helper()
+
+	// srcDir and its siblings are on their own file system, any rename leaving it fails
+	rename = func(oldpath, newpath string) error {
+		if oldpath != srcDir {
+			return os.Rename(oldpath, newpath)
+		}
+
+		if filepath.Dir(newpath) == filepath.Dir(srcDir) && !failStaging {
+			return os.Rename(oldpath, newpath)
+		}
+
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+
+	t.Cleanup(func() {
+		rename = os.Rename
+	})
+}
+
+func TestMoveDirectoryAcrossFileSystems(t *testing.T) {
+	t.Run("the copy keeps modification times and recent files follow", func(t *testing.T) {
+		dir, dh := createTempDir(t, "testMoveCopy")
+		defer os.RemoveAll(dir)
+		srcDir := createDirHelper(t, dir, "srcDir")
+		createDirHelper(t, dir, "destDir")
+		simulateCrossDevice(t, srcDir, false)
+
+		fName := filepath.Join(srcDir, "test.json")
+		err := os.WriteFile(fName, []byte("{}"), 0600)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		mtime := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+		os.Chtimes(fName, mtime, mtime)
+		dh.recent.AddRecentFile("srcDir/test.json")
+
+		err = dh.MoveDir("srcDir", "destDir")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		movedFile := filepath.Join(dir, "destDir", "srcDir", "test.json")
+		stat, err := os.Stat(movedFile)
+		if err != nil {
+			t.Fatalf("the file was not moved: %v", err)
+		}
+
+		if !stat.ModTime().Equal(mtime) || stat.Mode().Perm() != 0600 {
+			t.Errorf("the file's metadata was not kept: %v %v", stat.ModTime(), stat.Mode().Perm())
+		}
+
+		assertDirDoesNotExists(t, srcDir)
+
+		if dh.recent.FilePaths[0] != "destDir/srcDir/test.json" {
+			t.Errorf("recent file was not updated, got %s", dh.recent.FilePaths[0])
+		}
+	})
+
+	t.Run("the copy is removed if the source can't be", func(t *testing.T) {
+		dir, dh := createTempDir(t, "testMoveRollback")
+		defer os.RemoveAll(dir)
+		srcDir := createDirHelper(t, dir, "srcDir")
+		createDirHelper(t, dir, "destDir")
+		simulateCrossDevice(t, srcDir, true)
+
+		err := os.WriteFile(filepath.Join(srcDir, "test.json"), []byte("{}"), 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = dh.MoveDir("srcDir", "destDir")
+		if err == nil {
+			t.Fatal("wanted an error but didn't get one")
+		}
+
+		assertFileExistence(t, filepath.Join(srcDir, "test.json"))
+		assertDirDoesNotExists(t, filepath.Join(dir, "destDir", "srcDir"))
+	})
+
+	t.Run("a directory with the same name in the destination is not overwritten", func(t *testing.T) {
+		dir, dh := createTempDir(t, "testMoveExisting")
+		defer os.RemoveAll(dir)
+		createDirHelper(t, dir, "srcDir")
+		createDirHelper(t, dir, filepath.Join("destDir", "srcDir"))
+
+		err := dh.MoveDir("srcDir", "destDir")
+		if !errors.Is(err, ErrDestinationExists) {
+			t.Errorf("got %v, want %v", err, ErrDestinationExists)
+		}
+	})
+}
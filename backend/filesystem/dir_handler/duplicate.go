@@ -0,0 +1,204 @@
+package dirhandler
+
+import (
+	"context"
+	"encoding/json"
+	"flow-poc/backend/filesystem/fsutil"
+	"flow-poc/backend/filesystem/node"
+	"flow-poc/backend/graph"
+	"flow-poc/backend/jobs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Copies a directory and everything inside it next to the original. The copy is named like
+// the original with a number appended to it. If rewriteReferences is true, medias referenced by
+// the copied graphs that are located inside the copied directory are replaced by their copies
+// so the new graphs don't depend on the original directory
+func (dh *DirHandler) DuplicateDirectory(pathFromLabRoot string, rewriteReferences bool) (node.Node, error) {
+	return dh.duplicateDirectory(context.Background(), pathFromLabRoot, rewriteReferences, nil)
+}
+
+// Same as DuplicateDirectory but runs as a cancellable job. The new directory's node is the
+// result of the job. Returns the job's id
+func (dh *DirHandler) StartDuplicateDirectory(pathFromLabRoot string, rewriteReferences bool) string {
+	return dh.jobs.Start("duplicate", filepath.ToSlash(pathFromLabRoot), func(ctx context.Context, r *jobs.Reporter) (any, error) {
+		size, items, err := fsutil.Size(filepath.Join(dh.GetLabPath(), pathFromLabRoot))
+		if err != nil {
+			return nil, &DuplicateDirError{pathFromLabRoot, err}
+		}
+		r.SetTotal(size, items)
+
+		return dh.duplicateDirectory(ctx, pathFromLabRoot, rewriteReferences, r)
+	})
+}
+
+func (dh *DirHandler) duplicateDirectory(ctx context.Context, pathFromLabRoot string, rewriteReferences bool, progress fsutil.Progress) (node.Node, error) {
+	labPath := dh.GetLabPath()
+	p := filepath.Join(labPath, pathFromLabRoot)
+	if p == filepath.Clean(labPath) {
+		return node.Node{}, &DuplicateDirError{pathFromLabRoot, ErrDuplicateLabRoot}
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return node.Node{}, &DuplicateDirError{pathFromLabRoot, err}
+	}
+
+	if !info.IsDir() {
+		return node.Node{}, &DuplicateDirError{pathFromLabRoot, ErrNotADirectory}
+	}
+
+	np := filepath.Join(filepath.Dir(p), nonDuplicateDirName(p))
+
+	var onFile func(src, dst string) error
+	if rewriteReferences {
+		onFile = func(src, dst string) error {
+			if node.DetectFileTypeFromPath(dst) != node.GRAPH {
+				return nil
+			}
+
+			return dh.rewriteGraphReferences(dst, p, np)
+		}
+	}
+
+	err = fsutil.CopyDirContext(ctx, p, np, onFile, progress)
+	if err != nil {
+		return node.Node{}, &DuplicateDirError{pathFromLabRoot, err}
+	}
+
+	return node.NewNode(filepath.Base(np), "", node.DIR), nil
+}
+
+// Points the medias of a copied graph that were inside oldDir to their equivalent inside newDir.
+// Medias are referenced either by an absolute path or by a path starting from the lab root
+func (dh *DirHandler) rewriteGraphReferences(graphPath, oldDir, newDir string) error {
+	b, err := os.ReadFile(graphPath)
+	if err != nil {
+		return err
+	}
+
+	var g graph.Graph
+	// Files that can't be parsed are kept as they are
+	if err := json.Unmarshal(b, &g); err != nil {
+		return nil
+	}
+
+	labPath := dh.GetLabPath()
+	oldRel := dh.slashedFromLabRoot(oldDir)
+	newRel := dh.slashedFromLabRoot(newDir)
+
+	changed := false
+	for i, n := range g.Nodes {
+		img := n.Data.Image
+		if img != "" {
+			var newImg string
+			if filepath.IsAbs(img) && fsutil.IsInside(oldDir, img) {
+				rel, _ := filepath.Rel(oldDir, img)
+				newImg = filepath.Join(newDir, rel)
+			} else if !filepath.IsAbs(img) && fsutil.IsInside(oldDir, filepath.Join(labPath, img)) {
+				newImg = newRel + strings.TrimPrefix(filepath.ToSlash(filepath.Clean(img)), oldRel)
+			}
+
+			if newImg != "" {
+				g.Nodes[i].Data.Image = newImg
+				changed = true
+			}
+		}
+
+		// Medias mentioned in the text of a node
+		if text, ok := replaceDirInText(n.Data.Text, oldRel, newRel); ok {
+			g.Nodes[i].Data.Text = text
+			changed = true
+		}
+	}
+
+	for i, e := range g.Edges {
+		if label, ok := replaceDirInText(e.Label, oldRel, newRel); ok {
+			g.Edges[i].Label = label
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	b, err = json.MarshalIndent(g, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(graphPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(graphPath, b, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	return os.Chtimes(graphPath, info.ModTime(), info.ModTime())
+}
+
+// Replaces the paths starting with oldDir in a text by the same paths starting with newDir.
+// A path only matches if oldDir isn't the end of a longer name or path: with oldDir "Sol",
+// "Sol/oki.png" is replaced but "Medias/Sol/oki.png" and "Solo/oki.png" aren't.
+// Returns false if nothing was replaced
+func replaceDirInText(text, oldDir, newDir string) (string, bool) {
+	prefix := oldDir + "/"
+	var b strings.Builder
+	replaced := false
+
+	for {
+		i := indexAtSegmentStart(text, prefix)
+		if i == -1 {
+			break
+		}
+
+		b.WriteString(text[:i])
+		b.WriteString(newDir + "/")
+		text = text[i+len(prefix):]
+		replaced = true
+	}
+
+	b.WriteString(text)
+	return b.String(), replaced
+}
+
+// Returns the index of the first occurrence of prefix that isn't preceded by a character
+// of a path, -1 if there's none
+func indexAtSegmentStart(text, prefix string) int {
+	offset := 0
+	for {
+		i := strings.Index(text[offset:], prefix)
+		if i == -1 {
+			return -1
+		}
+
+		i += offset
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		if i == 0 || !isPathRune(before) {
+			return i
+		}
+
+		offset = i + 1
+	}
+}
+
+func isPathRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("/\\._-", r)
+}
+
+func (dh *DirHandler) slashedFromLabRoot(absPath string) string {
+	rel, err := filepath.Rel(dh.GetLabPath(), absPath)
+	if err != nil {
+		return filepath.ToSlash(absPath)
+	}
+
+	return filepath.ToSlash(rel)
+}
@@ -13,3 +13,42 @@ func (g *GetLabDirsError) Error() string {
 func (g *GetLabDirsError) Unwrap() error {
 	return g.err
 }
+
+type DuplicateDirError struct {
+	path string
+	err  error
+}
+
+func (d *DuplicateDirError) Error() string {
+	return fmt.Sprintf("couldn't duplicate directory %s: %v", d.path, d.err)
+}
+
+func (d *DuplicateDirError) Unwrap() error {
+	return d.err
+}
+
+type MoveDirError struct {
+	path string
+	err  error
+}
+
+func (m *MoveDirError) Error() string {
+	return fmt.Sprintf("couldn't move directory %s: %v", m.path, m.err)
+}
+
+func (m *MoveDirError) Unwrap() error {
+	return m.err
+}
+
+type DeleteDirError struct {
+	path string
+	err  error
+}
+
+func (d *DeleteDirError) Error() string {
+	return fmt.Sprintf("couldn't delete directory %s: %v", d.path, d.err)
+}
+
+func (d *DeleteDirError) Unwrap() error {
+	return d.err
+}
@@ -7,21 +7,26 @@ import (
 )
 
 func createNonDuplicateDir(absPath string) (string, error) {
+	name := nonDuplicateDirName(absPath)
+
+	err := os.Mkdir(filepath.Join(filepath.Dir(absPath), name), os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// Returns the first name made of the directory's name followed by a number
+// that isn't already taken in its parent directory
+func nonDuplicateDirName(absPath string) string {
 	p := filepath.Dir(absPath)
 	b := filepath.Base(absPath)
 
 	for i := 1; ; i++ {
 		name := fmt.Sprintf("%s %d", b, i)
-		fp := filepath.Join(p, name)
-		if doesDirExists(fp) {
-			continue
-		}
-
-		err := os.Mkdir(fp, os.ModeAppend)
-		if err != nil {
-			return "", err
+		if !doesDirExists(filepath.Join(p, name)) {
+			return name
 		}
-
-		return name, nil
 	}
 }
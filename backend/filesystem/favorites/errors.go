@@ -0,0 +1,41 @@
+package favorites
+
+import "fmt"
+
+type FavoritesFileError struct {
+	err error
+}
+
+func (f *FavoritesFileError) Error() string {
+	return fmt.Sprintf("couldn't access favorites: %v", f.err)
+}
+
+func (f *FavoritesFileError) Unwrap() error {
+	return f.err
+}
+
+type FavoriteError struct {
+	path string
+	err  error
+}
+
+func (f *FavoriteError) Error() string {
+	return fmt.Sprintf("%s: %v", f.path, f.err)
+}
+
+func (f *FavoriteError) Unwrap() error {
+	return f.err
+}
+
+type SectionError struct {
+	name string
+	err  error
+}
+
+func (s *SectionError) Error() string {
+	return fmt.Sprintf("section %s: %v", s.name, s.err)
+}
+
+func (s *SectionError) Unwrap() error {
+	return s.err
+}
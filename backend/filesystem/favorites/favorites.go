@@ -0,0 +1,379 @@
+// This package handles the files and directories pinned by the user. Unlike recent files,
+// favorites are never evicted: they stay until the user unpins them or the element is deleted.
+// They are ordered and can be grouped into named sections. Favorites are saved in the
+// .labmonster directory of the lab after each change.
+package favorites
+
+import (
+	"encoding/json"
+	"errors"
+	"flow-poc/backend/config"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const favoritesFilename = "favorites.json"
+
+var (
+	ErrAlreadyFavorite  = errors.New("this element is already pinned")
+	ErrNotAFavorite     = errors.New("this element is not pinned")
+	ErrSectionExists    = errors.New("a section with this name already exists")
+	ErrSectionNotFound  = errors.New("section not found")
+	ErrEmptySectionName = errors.New("a section needs a name")
+	ErrDefaultSection   = errors.New("the default section can't be renamed, moved or removed")
+)
+
+// Favorites grouped under a name. The first section is always the default one: it has
+// no name and holds the favorites that weren't put into a section
+type Section struct {
+	Name string `json:"name"`
+	// Paths starting from the lab root, in the order chosen by the user
+	Paths []string `json:"paths"`
+}
+
+type favoritesFile struct {
+	Sections []Section `json:"sections"`
+}
+
+type Favorites struct {
+	Cfg *config.AppConfig
+	// Favorites are read from the lab on each call so switching labs needs no reload
+	mu sync.Mutex
+}
+
+func NewFavorites(cfg *config.AppConfig) *Favorites {
+	return &Favorites{
+		Cfg: cfg,
+	}
+}
+
+func (f *Favorites) getLabPath() string {
+	return f.Cfg.LabPath()
+}
+
+// Returns every section in order, starting with the default one
+func (f *Favorites) GetFavorites() ([]Section, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.load()
+}
+
+// Pins an existing element at the end of a section. An empty section name means the default
+// section. The section is created if it doesn't exist
+func (f *Favorites) AddFavorite(pathFromLabRoot, section string) error {
+	labPath := f.getLabPath()
+
+	return f.update(func(sections []Section) ([]Section, error) {
+		p := clean(pathFromLabRoot)
+		if _, err := os.Stat(filepath.Join(labPath, p)); err != nil {
+			return nil, &FavoriteError{p, err}
+		}
+
+		if _, _, found := find(sections, p); found {
+			return nil, &FavoriteError{p, ErrAlreadyFavorite}
+		}
+
+		i := sectionIndex(sections, section)
+		if i == -1 {
+			sections = append(sections, Section{Name: strings.TrimSpace(section), Paths: []string{}})
+			i = len(sections) - 1
+		}
+
+		sections[i].Paths = append(sections[i].Paths, p)
+		return sections, nil
+	})
+}
+
+// Unpins an element
+func (f *Favorites) RemoveFavorite(pathFromLabRoot string) error {
+	return f.update(func(sections []Section) ([]Section, error) {
+		p := clean(pathFromLabRoot)
+		s, i, found := find(sections, p)
+		if !found {
+			return nil, &FavoriteError{p, ErrNotAFavorite}
+		}
+
+		sections[s].Paths = slices.Delete(sections[s].Paths, i, i+1)
+		return sections, nil
+	})
+}
+
+// Moves a favorite to the given position of a section, which can be the one it's already in.
+// An index out of range puts it at the end
+func (f *Favorites) MoveFavorite(pathFromLabRoot, section string, index int) error {
+	return f.update(func(sections []Section) ([]Section, error) {
+		p := clean(pathFromLabRoot)
+		s, i, found := find(sections, p)
+		if !found {
+			return nil, &FavoriteError{p, ErrNotAFavorite}
+		}
+
+		dest := sectionIndex(sections, section)
+		if dest == -1 {
+			return nil, &SectionError{section, ErrSectionNotFound}
+		}
+
+		sections[s].Paths = slices.Delete(sections[s].Paths, i, i+1)
+		sections[dest].Paths = slices.Insert(sections[dest].Paths, clamp(index, len(sections[dest].Paths)), p)
+		return sections, nil
+	})
+}
+
+// Adds an empty section after the existing ones
+func (f *Favorites) CreateSection(name string) error {
+	return f.update(func(sections []Section) ([]Section, error) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, ErrEmptySectionName
+		}
+
+		if sectionIndex(sections, name) != -1 {
+			return nil, &SectionError{name, ErrSectionExists}
+		}
+
+		return append(sections, Section{Name: name, Paths: []string{}}), nil
+	})
+}
+
+func (f *Favorites) RenameSection(oldName, newName string) error {
+	return f.update(func(sections []Section) ([]Section, error) {
+		i, err := namedSectionIndex(sections, oldName)
+		if err != nil {
+			return nil, err
+		}
+
+		newName = strings.TrimSpace(newName)
+		if newName == "" {
+			return nil, ErrEmptySectionName
+		}
+
+		if j := sectionIndex(sections, newName); j != -1 && j != i {
+			return nil, &SectionError{newName, ErrSectionExists}
+		}
+
+		sections[i].Name = newName
+		return sections, nil
+	})
+}
+
+// Moves a section to the given position. The default section always stays first
+func (f *Favorites) MoveSection(name string, index int) error {
+	return f.update(func(sections []Section) ([]Section, error) {
+		i, err := namedSectionIndex(sections, name)
+		if err != nil {
+			return nil, err
+		}
+
+		s := sections[i]
+		sections = slices.Delete(sections, i, i+1)
+		index = max(clamp(index, len(sections)), 1)
+		return slices.Insert(sections, index, s), nil
+	})
+}
+
+// Removes a section. Its favorites are moved to the end of the default section
+func (f *Favorites) RemoveSection(name string) error {
+	return f.update(func(sections []Section) ([]Section, error) {
+		i, err := namedSectionIndex(sections, name)
+		if err != nil {
+			return nil, err
+		}
+
+		sections[0].Paths = append(sections[0].Paths, sections[i].Paths...)
+		return slices.Delete(sections, i, i+1), nil
+	})
+}
+
+// Replaces oldPathFromRoot by newPathFromRoot in every favorite. Favorites located inside a
+// renamed or moved directory follow it. Used after renaming or moving a file or a directory.
+// Like recent files, a favorites file that can't be updated doesn't make the operation fail
+func (f *Favorites) ReconcilePaths(oldPathFromRoot, newPathFromRoot string) {
+	oldPath := clean(oldPathFromRoot)
+	newPath := clean(newPathFromRoot)
+
+	f.update(func(sections []Section) ([]Section, error) {
+		changed := false
+		for _, s := range sections {
+			for i, p := range s.Paths {
+				if p == oldPath {
+					s.Paths[i] = newPath
+					changed = true
+				} else if strings.HasPrefix(p, oldPath+"/") {
+					s.Paths[i] = newPath + strings.TrimPrefix(p, oldPath)
+					changed = true
+				}
+			}
+		}
+
+		if !changed {
+			return nil, errUnchanged
+		}
+
+		return sections, nil
+	})
+}
+
+// Unpins an element and everything inside it if it's a directory
+func (f *Favorites) removeUnder(pathFromLabRoot string) {
+	removed := clean(pathFromLabRoot)
+
+	f.update(func(sections []Section) ([]Section, error) {
+		changed := false
+		for i := range sections {
+			n := len(sections[i].Paths)
+			sections[i].Paths = slices.DeleteFunc(sections[i].Paths, func(p string) bool {
+				return p == removed || strings.HasPrefix(p, removed+"/")
+			})
+			changed = changed || len(sections[i].Paths) != n
+		}
+
+		if !changed {
+			return nil, errUnchanged
+		}
+
+		return sections, nil
+	})
+}
+
+// Unpins every element that doesn't exist anymore. Used after deleting files or directories
+func (f *Favorites) CheckIfFavoritesStillExist() {
+	labPath := f.getLabPath()
+
+	f.update(func(sections []Section) ([]Section, error) {
+		for i := range sections {
+			sections[i].Paths = slices.DeleteFunc(sections[i].Paths, func(p string) bool {
+				_, err := os.Stat(filepath.Join(labPath, p))
+				return errors.Is(err, os.ErrNotExist)
+			})
+		}
+
+		return sections, nil
+	})
+}
+
+// Returned by the function given to update when there's nothing to save
+var errUnchanged = errors.New("favorites unchanged")
+
+// Loads the favorites, applies fn and saves the result if fn didn't fail
+func (f *Favorites) update(fn func([]Section) ([]Section, error)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sections, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	sections, err = fn(sections)
+	if errors.Is(err, errUnchanged) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return f.save(sections)
+}
+
+func (f *Favorites) load() ([]Section, error) {
+	b, err := os.ReadFile(f.getFilePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return []Section{{Name: "", Paths: []string{}}}, nil
+	}
+
+	if err != nil {
+		return nil, &FavoritesFileError{err}
+	}
+
+	var ff favoritesFile
+	if err := json.Unmarshal(b, &ff); err != nil {
+		return nil, &FavoritesFileError{err}
+	}
+
+	if len(ff.Sections) == 0 || ff.Sections[0].Name != "" {
+		ff.Sections = append([]Section{{Name: "", Paths: []string{}}}, ff.Sections...)
+	}
+
+	for i := range ff.Sections {
+		if ff.Sections[i].Paths == nil {
+			ff.Sections[i].Paths = []string{}
+		}
+	}
+
+	return ff.Sections, nil
+}
+
+func (f *Favorites) save(sections []Section) error {
+	b, err := json.MarshalIndent(favoritesFile{sections}, "", "\t")
+	if err != nil {
+		return &FavoritesFileError{err}
+	}
+
+	p := f.getFilePath()
+	err = os.MkdirAll(filepath.Dir(p), os.ModePerm)
+	if err != nil {
+		return &FavoritesFileError{err}
+	}
+
+	err = os.WriteFile(p, b, 0644)
+	if err != nil {
+		return &FavoritesFileError{err}
+	}
+
+	return nil
+}
+
+func (f *Favorites) getFilePath() string {
+	return filepath.Join(f.getLabPath(), ".labmonster", favoritesFilename)
+}
+
+// Returns the index of the section and the index of the path inside it
+func find(sections []Section, p string) (int, int, bool) {
+	for s, section := range sections {
+		if i := slices.Index(section.Paths, p); i != -1 {
+			return s, i, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+func sectionIndex(sections []Section, name string) int {
+	name = strings.TrimSpace(name)
+	return slices.IndexFunc(sections, func(s Section) bool {
+		return s.Name == name
+	})
+}
+
+// Same as sectionIndex but refuses the default section
+func namedSectionIndex(sections []Section, name string) (int, error) {
+	if strings.TrimSpace(name) == "" {
+		return 0, ErrDefaultSection
+	}
+
+	i := sectionIndex(sections, name)
+	if i == -1 {
+		return 0, &SectionError{name, ErrSectionNotFound}
+	}
+
+	return i, nil
+}
+
+func clamp(index, length int) int {
+	if index < 0 || index > length {
+		return length
+	}
+
+	return index
+}
+
+// Paths are stored with forward slashes and without leading slash
+func clean(pathFromLabRoot string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(pathFromLabRoot)), "/")
+}
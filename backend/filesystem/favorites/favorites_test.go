@@ -0,0 +1,193 @@
+package favorites
+
+import (
+	"errors"
+	"flow-poc/backend/config"
+	"flow-poc/backend/watcher"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func createTempLab(t testing.TB) (string, *Favorites) {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "testFavorites")
+	if err != nil {
+		t.Fatalf("an error occured while creating temporary directory: %v", err)
+	}
+
+	return dir, NewFavorites(&config.AppConfig{
+		ConfigFile: config.ConfigFile{
+			LabPath: dir,
+		},
+	})
+}
+
+// Pins the given elements, creating the ones that don't exist as empty files
+func addHelper(t testing.TB, f *Favorites, section string, paths ...string) {
+	t.Helper()
+
+	for _, p := range paths {
+		abs := filepath.Join(f.getLabPath(), p)
+		if _, err := os.Stat(abs); err != nil {
+			os.MkdirAll(filepath.Dir(abs), os.ModePerm)
+			if err := os.WriteFile(abs, nil, 0644); err != nil {
+				t.Fatalf("couldn't create %s: %v", p, err)
+			}
+		}
+
+		if err := f.AddFavorite(p, section); err != nil {
+			t.Fatalf("couldn't add favorite %s: %v", p, err)
+		}
+	}
+}
+
+func getHelper(t testing.TB, f *Favorites) []Section {
+	t.Helper()
+
+	sections, err := f.GetFavorites()
+	if err != nil {
+		t.Fatalf("couldn't get favorites: %v", err)
+	}
+
+	return sections
+}
+
+func TestFavorites(t *testing.T) {
+	t.Run("favorites are ordered and grouped into sections", func(t *testing.T) {
+		dir, f := createTempLab(t)
+		defer os.RemoveAll(dir)
+
+		addHelper(t, f, "", "oki.json", "/Sol/bnb.json")
+		addHelper(t, f, "Sol", "Sol/punish.json")
+
+		err := f.AddFavorite("Sol/bnb.json", "Sol")
+		if !errors.Is(err, ErrAlreadyFavorite) {
+			t.Errorf("got %v, want %v", err, ErrAlreadyFavorite)
+		}
+
+		err = f.AddFavorite("Sol/missing.json", "Sol")
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got %v, want %v", err, os.ErrNotExist)
+		}
+
+		err = f.MoveFavorite("Sol/bnb.json", "Sol", 0)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		// Saved favorites are read again by a new instance
+		sections := getHelper(t, NewFavorites(f.Cfg))
+		if len(sections) != 2 || sections[0].Name != "" || sections[1].Name != "Sol" {
+			t.Fatalf("wrong sections: %+v", sections)
+		}
+
+		if !slices.Equal(sections[0].Paths, []string{"oki.json"}) || !slices.Equal(sections[1].Paths, []string{"Sol/bnb.json", "Sol/punish.json"}) {
+			t.Errorf("wrong order: %+v", sections)
+		}
+	})
+
+	t.Run("the default section can't be changed", func(t *testing.T) {
+		dir, f := createTempLab(t)
+		defer os.RemoveAll(dir)
+
+		if err := f.RemoveSection(""); !errors.Is(err, ErrDefaultSection) {
+			t.Errorf("got %v, want %v", err, ErrDefaultSection)
+		}
+
+		if err := f.CreateSection(" "); !errors.Is(err, ErrEmptySectionName) {
+			t.Errorf("got %v, want %v", err, ErrEmptySectionName)
+		}
+
+		addHelper(t, f, "Ky", "Ky/oki.json")
+		addHelper(t, f, "Sol", "Sol/oki.json")
+		if err := f.MoveSection("Sol", 0); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		sections := getHelper(t, f)
+		if sections[0].Name != "" || sections[1].Name != "Sol" {
+			t.Errorf("the default section should stay first: %+v", sections)
+		}
+
+		if err := f.RemoveSection("Ky"); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		sections = getHelper(t, f)
+		if len(sections) != 2 || !slices.Equal(sections[0].Paths, []string{"Ky/oki.json"}) {
+			t.Errorf("favorites of a removed section should go to the default one: %+v", sections)
+		}
+	})
+}
+
+func TestReconcileFavorites(t *testing.T) {
+	dir, f := createTempLab(t)
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "Sol", "Combos"), os.ModePerm)
+	addHelper(t, f, "", "Sol", "Sol/Combos/bnb.json", "Sol/oki.json", "Solo.json")
+
+	f.ReconcilePaths("Sol", "Sol Badguy")
+	sections := getHelper(t, f)
+	want := []string{"Sol Badguy", "Sol Badguy/Combos/bnb.json", "Sol Badguy/oki.json", "Solo.json"}
+	if !slices.Equal(sections[0].Paths, want) {
+		t.Fatalf("got %v, want %v", sections[0].Paths, want)
+	}
+
+	os.Rename(filepath.Join(dir, "Sol"), filepath.Join(dir, "Sol Badguy"))
+	os.Remove(filepath.Join(dir, "Sol Badguy", "oki.json"))
+	f.CheckIfFavoritesStillExist()
+
+	sections = getHelper(t, f)
+	want = []string{"Sol Badguy", "Sol Badguy/Combos/bnb.json", "Solo.json"}
+	if !slices.Equal(sections[0].Paths, want) {
+		t.Errorf("got %v, want %v", sections[0].Paths, want)
+	}
+
+	t.Run("nothing is written when no favorite matches", func(t *testing.T) {
+		p := f.getFilePath()
+		before, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("couldn't stat the favorites file: %v", err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		f.ReconcilePaths("Ky", "Ky Kiske")
+
+		after, err := os.Stat(p)
+		if err != nil || !after.ModTime().Equal(before.ModTime()) {
+			t.Errorf("the favorites file shouldn't have been written: %v", err)
+		}
+	})
+}
+
+func TestHandleFsEvent(t *testing.T) {
+	dir, f := createTempLab(t)
+	defer os.RemoveAll(dir)
+	addHelper(t, f, "", "Sol/oki.json", "Ky/oki.json", "setup.webm")
+
+	f.HandleFsEvent(watcher.Event{
+		Op:      watcher.Rename,
+		Path:    filepath.Join(dir, "Sol Badguy"),
+		OldPath: filepath.Join(dir, "Sol"),
+	})
+	f.HandleFsEvent(watcher.Event{
+		Op:      watcher.Move,
+		Path:    filepath.Join(dir, "Médias", "setup.webm"),
+		OldPath: filepath.Join(dir, "setup.webm"),
+	})
+	f.HandleFsEvent(watcher.Event{
+		Op:      watcher.Remove,
+		Path:    filepath.Join(dir, "Ky"),
+		OldPath: filepath.Join(dir, "Ky"),
+	})
+
+	want := []string{"Sol Badguy/oki.json", "Médias/setup.webm"}
+	if sections := getHelper(t, f); !slices.Equal(sections[0].Paths, want) {
+		t.Errorf("got %v, want %v", sections[0].Paths, want)
+	}
+}
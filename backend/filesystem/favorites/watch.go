@@ -0,0 +1,37 @@
+package favorites
+
+import (
+	"flow-poc/backend/watcher"
+	"path/filepath"
+)
+
+// Keeps the favorites in sync with the elements renamed, moved or deleted outside of the app
+func (f *Favorites) Watch(w *watcher.Watcher) {
+	w.Subscribe(f.HandleFsEvent)
+}
+
+// Reconciles the favorites with an event of the watcher. The paths of the event must be
+// absolute, as sent by the watcher
+func (f *Favorites) HandleFsEvent(e watcher.Event) {
+	if e.Op != watcher.Move && e.Op != watcher.Rename && e.Op != watcher.Remove {
+		return
+	}
+
+	labPath := f.getLabPath()
+	p, err := filepath.Rel(labPath, e.Path)
+	if err != nil {
+		return
+	}
+
+	if e.Op == watcher.Remove {
+		f.removeUnder(p)
+		return
+	}
+
+	old, err := filepath.Rel(labPath, e.OldPath)
+	if err != nil {
+		return
+	}
+
+	f.ReconcilePaths(old, p)
+}
@@ -47,3 +47,41 @@ func (s *SaveFileError) Error() string {
 func (s *SaveFileError) Unwrap() error {
 	return s.err
 }
+
+type FindOrphanedMediaError struct {
+	err error
+}
+
+func (f *FindOrphanedMediaError) Error() string {
+	return fmt.Sprintf("couldn't look for orphaned medias: %v", f.err)
+}
+
+func (f *FindOrphanedMediaError) Unwrap() error {
+	return f.err
+}
+
+type OrphanedMediaError struct {
+	path string
+	err  error
+}
+
+func (o *OrphanedMediaError) Error() string {
+	return fmt.Sprintf("can't clean up media %s: %v", o.path, o.err)
+}
+
+func (o *OrphanedMediaError) Unwrap() error {
+	return o.err
+}
+
+type ImportError struct {
+	path string
+	err  error
+}
+
+func (i *ImportError) Error() string {
+	return fmt.Sprintf("couldn't import into %s: %v", i.path, i.err)
+}
+
+func (i *ImportError) Unwrap() error {
+	return i.err
+}
@@ -2,15 +2,23 @@
 package file_handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/favorites"
+	"flow-poc/backend/filesystem/fsutil"
+	"flow-poc/backend/filesystem/labignore"
 	"flow-poc/backend/filesystem/node"
 	"flow-poc/backend/filesystem/recentfiles"
 	"flow-poc/backend/graph"
+	"flow-poc/backend/jobs"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -30,36 +38,48 @@ type FileHandler struct {
 	// App's configuration
 	Cfg         *config.AppConfig
 	RecentFiles *recentfiles.RecentlyOpened
+	Favorites   *favorites.Favorites
+	jobs        *jobs.Manager
+	tree        treeSnapshot
 }
 
-func NewFileHandler(cfg *config.AppConfig) *FileHandler {
+func NewFileHandler(cfg *config.AppConfig, jm *jobs.Manager) *FileHandler {
 	fh := &FileHandler{
 		Cfg:         cfg,
 		RecentFiles: recentfiles.NewRecentlyOpened(cfg, maxRecentlyOpenedFiles),
+		Favorites:   favorites.NewFavorites(cfg),
+		jobs:        jm,
 	}
+	cfg.Subscribe(fh.onConfigChange)
 
 	return fh
 }
 
 func (fh *FileHandler) GetLabPath() string {
-	return fh.Cfg.ConfigFile.LabPath
+	return fh.Cfg.LabPath()
 }
 
 func (fh *FileHandler) GetRecentlyOpenedFiles() ([]string, error) {
 	return fh.RecentFiles.GetRecentlyOpenedFiles()
 }
 
+// Returns the files opened during the given time window with their open time and count,
+// ranked by frecency
+func (fh *FileHandler) GetRecentFiles(window recentfiles.TimeWindow) ([]recentfiles.RecentFile, error) {
+	return fh.RecentFiles.GetRecentFiles(window)
+}
+
 // Given a path to a directory starting from the lab root, this function will read
 // its content using the os.ReadDir method, transforms those entries into Nodes
 // and return them
 func (fh *FileHandler) GetSubDirAndFiles(pathFromLabRoot string) ([]*node.Node, error) {
 	dirPath := filepath.Join(fh.GetLabPath(), pathFromLabRoot)
-	entries, err := os.ReadDir(dirPath)
+	entries, err := fh.readLabDir(dirPath)
 	if err != nil {
 		return nil, &GetSubDirAndFilesError{err}
 	}
 
-	nodes, err := node.CreateNodesFromDirEntries(entries)
+	nodes, err := node.CreateNodesFromDirEntries(dirPath, entries)
 	if err != nil {
 		return nil, &GetSubDirAndFilesError{err}
 	}
@@ -71,32 +91,10 @@ func (fh *FileHandler) CreateFile(pathFromLabRoot string) (node.Node, error) {
 	p := filepath.Join(fh.GetLabPath(), pathFromLabRoot)
 	g := graph.GetInitGraph()
 
-	if !doesFileExist(p) {
-		f, err := os.Create(p)
-		if err != nil {
-			return node.Node{}, err
-		}
-		defer f.Close()
-
-		stat, err := f.Stat()
-		if err != nil {
-			return node.Node{}, err
-		}
-
-		err = writeFile(g, f)
-		if err != nil {
-			return node.Node{}, err
-		}
-
-		n := node.NewNode(stat.Name(), ".json", node.FILE)
-		return n, nil
-	}
-
-	f, name, err := createNonDuplicateFile(p)
+	f, err := os.Create(fsutil.NonDuplicatePath(p, false))
 	if err != nil {
 		return node.Node{}, err
 	}
-
 	defer f.Close()
 
 	err = writeFile(g, f)
@@ -104,7 +102,7 @@ func (fh *FileHandler) CreateFile(pathFromLabRoot string) (node.Node, error) {
 		return node.Node{}, err
 	}
 
-	n := node.NewNode(name, ".json", node.FILE)
+	n := node.NewNode(filepath.Base(f.Name()), ".json", node.FILE)
 	return n, nil
 }
 
@@ -132,6 +130,11 @@ func (fh *FileHandler) SaveFile(pathFromLabRoot string, graphToSave graph.Graph)
 		return nil
 	}
 
+	// The editor doesn't send the metadata back, it's kept from the saved file
+	if graphToSave.Metadata == nil {
+		graphToSave.Metadata = readMetadata(path)
+	}
+
 	// Create truncates the file if it already exists
 	f, err := os.Create(path)
 	if err != nil {
@@ -147,7 +150,7 @@ func (fh *FileHandler) SaveFile(pathFromLabRoot string, graphToSave graph.Graph)
 	return nil
 }
 
-// Rename a file on the user's machine
+// Rename a file on the user's machine along with its sidecars
 func (fh *FileHandler) RenameFile(pathFromRootOfTheLab, oldName, newName string) error {
 	labPath := fh.GetLabPath()
 	oldPath := filepath.Join(labPath, pathFromRootOfTheLab, oldName)
@@ -158,26 +161,43 @@ func (fh *FileHandler) RenameFile(pathFromRootOfTheLab, oldName, newName string)
 		return err
 	}
 
-	// TODO: Renommer l'entrée qui va avec dans les fichiers récents
-	return nil
+	oldPathFromRoot := path.Join(pathFromRootOfTheLab, oldName)
+	newPathFromRoot := path.Join(pathFromRootOfTheLab, newName)
+	fh.RecentFiles.ReconcilePaths(oldPathFromRoot, newPathFromRoot)
+	fh.Favorites.ReconcilePaths(oldPathFromRoot, newPathFromRoot)
+	return node.MoveSidecars(oldPath, newPath)
 }
 
 // Given the path to a file starting from the lab root,
-// deletes a file on the user's machine and from the in-memory tree
+// deletes a file and its sidecars on the user's machine and from the in-memory tree
 func (fh *FileHandler) DeleteFile(pathFromRootOfTheLab string) error {
-	err := os.Remove(filepath.Join(fh.GetLabPath(), pathFromRootOfTheLab))
+	p := filepath.Join(fh.GetLabPath(), pathFromRootOfTheLab)
+	err := os.Remove(p)
 	if err != nil {
 		return err
 	}
 
 	fh.RecentFiles.RemoveRecent(pathFromRootOfTheLab)
+	fh.Favorites.RemoveFavorite(pathFromRootOfTheLab)
 
-	return nil
+	return node.RemoveSidecars(p)
 }
 
 // Given a path to a file starting from the lab root and an another path to a directory,
-// moves the file to the new directory.
+// moves the file and its sidecars to the new directory.
 func (fh *FileHandler) MoveFileToExistingDir(oldPath, newPath string) (string, error) {
+	name, err := fh.moveFileToExistingDir(oldPath, newPath)
+	if err != nil || name == "" {
+		return name, err
+	}
+
+	fh.Favorites.ReconcilePaths(oldPath, path.Join(newPath, name))
+
+	labPath := fh.GetLabPath()
+	return name, node.MoveSidecars(filepath.Join(labPath, oldPath), filepath.Join(labPath, newPath, name))
+}
+
+func (fh *FileHandler) moveFileToExistingDir(oldPath, newPath string) (string, error) {
 	if oldPath == newPath {
 		return "", ErrEqualOldAndNewPath
 	}
@@ -225,7 +245,8 @@ func (fh *FileHandler) DuplicateFile(pathToFileFromLabRoot, extension string) (n
 		return "", err
 	}
 
-	f2, name, err := createNonDuplicateFile(path)
+	np := fsutil.NonDuplicatePath(path, false)
+	f2, err := os.Create(np)
 	if err != nil {
 		return "", err
 	}
@@ -242,7 +263,63 @@ func (fh *FileHandler) DuplicateFile(pathToFileFromLabRoot, extension string) (n
 		return "", ErrNothingRead
 	}
 
-	return name, nil
+	return filepath.Base(np), node.CopySidecars(path, np)
+}
+
+// Same as DuplicateFile but runs as a cancellable job, meant for big medias. The copy keeps
+// the permissions and modification time of the original. The name of the new file is the
+// result of the job. Returns the job's id
+func (fh *FileHandler) StartDuplicateFile(pathToFileFromLabRoot, extension string) string {
+	return fh.jobs.Start("duplicate", filepath.ToSlash(pathToFileFromLabRoot+extension), func(ctx context.Context, r *jobs.Reporter) (any, error) {
+		path := filepath.Join(fh.GetLabPath(), pathToFileFromLabRoot+extension)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		r.SetTotal(info.Size(), 1)
+
+		np := fsutil.NonDuplicatePath(path, false)
+		err = fsutil.CopyFileContext(ctx, path, np, r)
+		if err != nil {
+			return nil, err
+		}
+
+		return filepath.Base(np), node.CopySidecars(path, np)
+	})
+}
+
+// Reads a directory of the lab, leaving out the entries ignored by the lab's .labignore
+func (fh *FileHandler) readLabDir(absPath string) ([]fs.DirEntry, error) {
+	entries, err := os.ReadDir(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ignore, err := labignore.ForLab(fh.GetLabPath())
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(entries, func(e fs.DirEntry) bool {
+		return ignore.Ignores(filepath.Join(absPath, e.Name()), e.IsDir())
+	}), nil
+}
+
+// Returns the metadata of the graph saved at path, nil if it has none or can't be read
+func readMetadata(path string) *graph.GraphMetadata {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var g struct {
+		Metadata *graph.GraphMetadata `json:"metadata"`
+	}
+	if json.Unmarshal(b, &g) != nil {
+		return nil
+	}
+
+	return g.Metadata
 }
 
 func writeFile(g graph.Graph, f *os.File) error {
@@ -11,7 +11,9 @@ import (
 	"testing"
 
 	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/node"
 	"flow-poc/backend/graph"
+	"flow-poc/backend/jobs"
 )
 
 // Creates dir by joining the last 2 args with filepath.Join. The first
@@ -41,11 +43,12 @@ func createTempDir(t testing.TB, testDirName, testFileName string) (string, *Fil
 		t.Fatalf("an error occured while creating temporary directory: %v %s", err, tempDir)
 	}
 
-	fh := NewFileHandler(&config.AppConfig{
+	c := &config.AppConfig{
 		ConfigFile: config.ConfigFile{
 			LabPath: dir,
 		},
-	})
+	}
+	fh := NewFileHandler(c, jobs.NewManager(c))
 
 	_, err = fh.CreateFile(testFileName)
 	if err != nil {
@@ -117,17 +120,39 @@ func TestGetSubDirAndFiles(t *testing.T) {
 		}
 	})
 
+	t.Run("files without extension are recognized by their content", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testSniffNodes", "graph.json")
+		defer os.RemoveAll(dir)
+
+		err := os.WriteFile(filepath.Join(dir, "capture"), []byte("GIF89a\x01\x00\x01\x00"), 0644)
+		if err != nil {
+			t.Fatalf("couldn't write file: %v", err)
+		}
+
+		nodes, err := ft.GetSubDirAndFiles("")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		for _, n := range nodes {
+			if n.Name == "capture" && n.FileType != node.IMAGE {
+				t.Errorf("got %s, want %s", n.FileType, node.IMAGE)
+			}
+		}
+	})
+
 	// TODO: Ajouter d'autres tests dans d'autres profondeurs lorsque la fonction de création sera
 	// implémentée
 }
 
 func getNewFileTreeExplorer() (*FileHandler, string) {
 	dir, _ := os.MkdirTemp("", "testFt")
-	return NewFileHandler(&config.AppConfig{
+	c := &config.AppConfig{
 		ConfigFile: config.ConfigFile{
 			LabPath: dir,
 		},
-	}), dir
+	}
+	return NewFileHandler(c, jobs.NewManager(c)), dir
 }
 
 func createFileBeforeTest(t testing.TB, ft *FileHandler, fileName string) {
@@ -338,6 +363,100 @@ func TestDuplicateFile(t *testing.T) {
 	})
 }
 
+// Writes an image and its annotations sidecar at the root of the lab
+func createImageWithSidecar(t testing.TB, labPath, name string) {
+	t.Helper()
+
+	for _, p := range []string{name, name + ".annotations"} {
+		if err := os.WriteFile(filepath.Join(labPath, p), []byte(p), 0644); err != nil {
+			t.Fatalf("couldn't create %s: %v", p, err)
+		}
+	}
+}
+
+func assertSidecar(t testing.TB, labPath, pathFromLabRoot string, want bool) {
+	t.Helper()
+
+	if got := doesFileExist(filepath.Join(labPath, pathFromLabRoot+".annotations")); got != want {
+		t.Errorf("sidecar of %s exists: got %v, want %v", pathFromLabRoot, got, want)
+	}
+}
+
+func TestSidecarsFollowTheirFile(t *testing.T) {
+	t.Run("renaming", func(t *testing.T) {
+		ft, dir := getNewFileTreeExplorer()
+		defer os.RemoveAll(dir)
+		createImageWithSidecar(t, dir, "setup.png")
+
+		if err := ft.RenameFile("", "setup.png", "oki.png"); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertSidecar(t, dir, "setup.png", false)
+		assertSidecar(t, dir, "oki.png", true)
+	})
+
+	t.Run("moving", func(t *testing.T) {
+		ft, dir := getNewFileTreeExplorer()
+		defer os.RemoveAll(dir)
+		createImageWithSidecar(t, dir, "setup.png")
+		createDirHelper(t, dir, "sub")
+
+		name, err := ft.MoveFileToExistingDir("setup.png", "sub")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertSidecar(t, dir, "setup.png", false)
+		assertSidecar(t, dir, filepath.Join("sub", name), true)
+	})
+
+	t.Run("deleting", func(t *testing.T) {
+		ft, dir := getNewFileTreeExplorer()
+		defer os.RemoveAll(dir)
+		createImageWithSidecar(t, dir, "setup.png")
+
+		if err := ft.DeleteFile("setup.png"); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertSidecar(t, dir, "setup.png", false)
+	})
+
+	t.Run("duplicating", func(t *testing.T) {
+		ft, dir := getNewFileTreeExplorer()
+		defer os.RemoveAll(dir)
+		createImageWithSidecar(t, dir, "setup.png")
+
+		name, err := ft.DuplicateFile("setup", ".png")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertSidecar(t, dir, "setup.png", true)
+		assertSidecar(t, dir, name, true)
+	})
+
+	t.Run("every sidecar of a file follows it", func(t *testing.T) {
+		ft, dir := getNewFileTreeExplorer()
+		defer os.RemoveAll(dir)
+		for _, p := range []string{"session.webm", "session.webm.tags", "session.webm.markers"} {
+			createFileHelper(t, dir, p)
+		}
+		createDirHelper(t, dir, "sub")
+
+		if _, err := ft.MoveFileToExistingDir("session.webm", "sub"); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		for _, ext := range []string{".tags", ".markers"} {
+			if !doesFileExist(filepath.Join(dir, "sub", "session.webm"+ext)) || doesFileExist(filepath.Join(dir, "session.webm"+ext)) {
+				t.Errorf("the %s sidecar didn't follow its file", ext)
+			}
+		}
+	})
+}
+
 func createFileHelper(t testing.TB, tempDirPath, completeFileName string) {
 	t.Helper()
 
@@ -436,6 +555,16 @@ func TestMoveFile(t *testing.T) {
 		assertFileExistence(t, dir, subDir1, subDir2)
 	})
 
+	t.Run("get an error when the file can't be moved", func(t *testing.T) {
+		dir, _ := createTempDir(t, "testMoveMissing", "testFile1.json")
+		defer os.RemoveAll(dir)
+
+		_, err := moveFile(filepath.Join(dir, "missing.json"), filepath.Join(dir, "moved.json"))
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got %v, want %v", err, os.ErrNotExist)
+		}
+	})
+
 	t.Run("get an error when paths are stricty identical", func(t *testing.T) {
 		subFile1 := "testFile1"
 		dir, ft := createTempDir(t, "testMoveToDir", subFile1)
@@ -593,3 +722,30 @@ func fileContentCompare(path, file1, file2 string) error {
 		}
 	}
 }
+
+func TestSaveFileKeepsMetadata(t *testing.T) {
+	dir, fh := createTempDir(t, "testSaveMetadata", "graph.json")
+	defer os.RemoveAll(dir)
+
+	g := graph.GetInitGraph()
+	g.Metadata = &graph.GraphMetadata{Tags: []string{"Sol"}}
+	err := fh.SaveFile("graph.json", g)
+	if err != nil {
+		t.Fatalf("couldn't save graph: %v", err)
+	}
+
+	// The editor saves graphs without their metadata
+	err = fh.SaveFile("graph.json", graph.GetInitGraph())
+	if err != nil {
+		t.Fatalf("couldn't save graph: %v", err)
+	}
+
+	saved, err := fh.OpenFile("graph.json")
+	if err != nil {
+		t.Fatalf("couldn't open graph: %v", err)
+	}
+
+	if saved.Metadata == nil || len(saved.Metadata.Tags) != 1 {
+		t.Errorf("the metadata was lost: %+v", saved.Metadata)
+	}
+}
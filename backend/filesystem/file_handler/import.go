@@ -0,0 +1,249 @@
+package file_handler
+
+import (
+	"errors"
+	"flow-poc/backend/filesystem/fsutil"
+	"flow-poc/backend/filesystem/node"
+	"flow-poc/backend/graph"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrImportNotSupported = errors.New("file type not supported")
+	ErrImportInsideLab    = errors.New("the path is already inside the lab")
+	ErrImportContainsLab  = errors.New("the path contains the lab")
+)
+
+type ImportOptions struct {
+	// Converts the formats the lab knows how to translate (Obsidian markdown notes and canvases)
+	// into graphs instead of skipping them
+	ConvertFormats bool `json:"convertFormats"`
+}
+
+// Result of the import of a single file
+type ImportResult struct {
+	// Absolute path of the imported file on the user's machine
+	Source string `json:"source"`
+	// Path of the new file starting from the lab root. Empty if the import failed
+	Path      string        `json:"path"`
+	FileType  node.FileType `json:"fileType"`
+	Converted bool          `json:"converted"`
+	// Empty if the import succeeded
+	Error string `json:"error"`
+}
+
+// Copies files and directories located outside of the lab into the directory given as a path starting
+// from the lab root. Directories are copied recursively and hidden elements inside them are ignored.
+// A file or directory that already exists at the destination is never overwritten: a number is appended
+// to the new element's name instead. Files the lab can't handle are skipped unless they can be converted.
+// Every file produces a result and a failure doesn't stop the import of the other ones
+func (fh *FileHandler) ImportPaths(externalPaths []string, destDirFromLabRoot string, opts ImportOptions) ([]ImportResult, error) {
+	labPath := fh.GetLabPath()
+	destDir := filepath.Join(labPath, destDirFromLabRoot)
+	info, err := os.Stat(destDir)
+	if err != nil {
+		return nil, &ImportError{destDir, err}
+	}
+
+	if !info.IsDir() {
+		return nil, &ImportError{destDir, fs.ErrInvalid}
+	}
+
+	results := make([]ImportResult, 0, len(externalPaths))
+	for _, p := range externalPaths {
+		p = filepath.Clean(p)
+		if p == filepath.Clean(labPath) || fsutil.IsInside(labPath, p) {
+			results = append(results, ImportResult{Source: p, Error: ErrImportInsideLab.Error()})
+			continue
+		}
+
+		// The copy would walk into the lab while filling it
+		if fsutil.IsInside(p, labPath) {
+			results = append(results, ImportResult{Source: p, Error: ErrImportContainsLab.Error()})
+			continue
+		}
+
+		info, err := os.Stat(p)
+		if err != nil {
+			results = append(results, ImportResult{Source: p, Error: err.Error()})
+			continue
+		}
+
+		if !info.IsDir() {
+			results = append(results, fh.importFile(p, destDir, filepath.ToSlash(destDirFromLabRoot), opts))
+			continue
+		}
+
+		results = append(results, fh.importDir(p, destDir, opts)...)
+	}
+
+	return results, nil
+}
+
+// Recreates the directory at the destination then imports every file inside it
+func (fh *FileHandler) importDir(srcDir, destDir string, opts ImportOptions) []ImportResult {
+	results := make([]ImportResult, 0)
+
+	root := fsutil.NonDuplicatePath(filepath.Join(destDir, filepath.Base(srcDir)), true)
+	err := os.Mkdir(root, os.ModePerm)
+	if err != nil {
+		return append(results, ImportResult{Source: srcDir, Error: err.Error()})
+	}
+
+	// Files embedded in converted canvases are referenced relatively to the imported directory
+	rootFromLabRoot, err := filepath.Rel(fh.GetLabPath(), root)
+	if err != nil {
+		return append(results, ImportResult{Source: srcDir, Error: err.Error()})
+	}
+
+	err = filepath.WalkDir(srcDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			results = append(results, ImportResult{Source: p, Error: err.Error()})
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if p == srcDir {
+			return nil
+		}
+
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(root, rel), os.ModePerm)
+		}
+
+		results = append(results, fh.importFile(p, filepath.Join(root, filepath.Dir(rel)), filepath.ToSlash(rootFromLabRoot), opts))
+		return nil
+	})
+
+	if err != nil {
+		results = append(results, ImportResult{Source: srcDir, Error: err.Error()})
+	}
+
+	return results
+}
+
+// Copies a single file into destDir, converting it if needed. filesPrefix is the path from
+// the lab root that files referenced by converted formats are relative to
+func (fh *FileHandler) importFile(src, destDir, filesPrefix string, opts ImportOptions) ImportResult {
+	res := ImportResult{Source: src}
+	format, known := node.FormatFromPath(src)
+	fileType := format.FileType
+	name := filepath.Base(src)
+
+	var (
+		dst string
+		err error
+	)
+
+	switch {
+	case known:
+		// Files recognized by their content only are given the canonical extension of their format
+		if _, ok := node.FormatFromExtension(filepath.Ext(name)); !ok {
+			name += format.Extension()
+		}
+
+		// A partially copied file is removed by the copy itself
+		dst = fsutil.NonDuplicatePath(filepath.Join(destDir, name), false)
+		err = fsutil.CopyFile(src, dst)
+	case opts.ConvertFormats && isConvertible(src):
+		var g graph.Graph
+		g, err = fh.convertFile(src, filesPrefix)
+		if err != nil {
+			break
+		}
+
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + ".json"
+		dst = fsutil.NonDuplicatePath(filepath.Join(destDir, name), false)
+		err = writeNewGraph(g, dst)
+
+		fileType = node.GRAPH
+		res.Converted = true
+	default:
+		err = ErrImportNotSupported
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	rel, err := filepath.Rel(fh.GetLabPath(), dst)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Path = filepath.ToSlash(rel)
+	res.FileType = fileType
+	return res
+}
+
+func (fh *FileHandler) convertFile(src, filesPrefix string) (graph.Graph, error) {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return graph.Graph{}, err
+	}
+
+	switch strings.ToLower(filepath.Ext(src)) {
+	case ".canvas":
+		return graph.FromObsidianCanvas(b, filesPrefix, func(file string) string {
+			switch node.DetectFileType(path.Ext(file)) {
+			case node.IMAGE:
+				return "image"
+			case node.VIDEO:
+				return "video"
+			default:
+				return ""
+			}
+		})
+	default:
+		return graph.FromMarkdown(string(b)), nil
+	}
+}
+
+func isConvertible(p string) bool {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".md", ".canvas":
+		return true
+	default:
+		return false
+	}
+}
+
+// Writes a converted graph to a new file. The file is removed if it can't be fully written
+func writeNewGraph(g graph.Graph, p string) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(g, f)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+
+	// Don't leave a partially written file in the lab
+	if err != nil {
+		os.Remove(p)
+	}
+
+	return err
+}
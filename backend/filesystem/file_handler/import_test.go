@@ -0,0 +1,209 @@
+package file_handler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"flow-poc/backend/config"
+	"flow-poc/backend/graph"
+	"flow-poc/backend/jobs"
+)
+
+// Creates a directory outside of the lab holding files to import
+func createExternalDir(t testing.TB) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "testExternal")
+	if err != nil {
+		t.Fatalf("an error occured while creating temporary directory: %v", err)
+	}
+
+	return dir
+}
+
+func writeExternalFile(t testing.TB, dir, name, content string) string {
+	t.Helper()
+
+	p := filepath.Join(dir, name)
+	err := os.MkdirAll(filepath.Dir(p), os.ModePerm)
+	if err != nil {
+		t.Fatalf("couldn't create parent directory: %v", err)
+	}
+
+	err = os.WriteFile(p, []byte(content), 0644)
+	if err != nil {
+		t.Fatalf("couldn't write external file: %v", err)
+	}
+
+	return p
+}
+
+func assertImportSucceeded(t testing.TB, results []ImportResult, want int) {
+	t.Helper()
+
+	ok := 0
+	for _, r := range results {
+		if r.Error == "" {
+			ok++
+		}
+	}
+
+	if ok != want {
+		t.Fatalf("want %d successful imports, got %d: %v", want, ok, results)
+	}
+}
+
+func TestImportPaths(t *testing.T) {
+	t.Run("import a file twice keeps both copies", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testImport", "graph.json")
+		defer os.RemoveAll(dir)
+		ext := createExternalDir(t)
+		defer os.RemoveAll(ext)
+		p := writeExternalFile(t, ext, "capture.png", "\x89PNG\r\n\x1a\n")
+
+		results, err := ft.ImportPaths([]string{p, p}, "", ImportOptions{})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertImportSucceeded(t, results, 2)
+		assertFileExistence(t, dir, "capture.png")
+		assertFileExistence(t, dir, "capture 1.png")
+	})
+
+	t.Run("imported files keep their modification time", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testImportTime", "graph.json")
+		defer os.RemoveAll(dir)
+		ext := createExternalDir(t)
+		defer os.RemoveAll(ext)
+		p := writeExternalFile(t, ext, "capture.png", "\x89PNG\r\n\x1a\n")
+		mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		os.Chtimes(p, mtime, mtime)
+
+		results, err := ft.ImportPaths([]string{p}, "", ImportOptions{})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertImportSucceeded(t, results, 1)
+		info, err := os.Stat(filepath.Join(dir, "capture.png"))
+		if err != nil {
+			t.Fatalf("couldn't stat imported file: %v", err)
+		}
+
+		if !info.ModTime().Equal(mtime) {
+			t.Errorf("got %v, want %v", info.ModTime(), mtime)
+		}
+	})
+
+	t.Run("files without extension are detected by their content", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testImportSniff", "graph.json")
+		defer os.RemoveAll(dir)
+		ext := createExternalDir(t)
+		defer os.RemoveAll(ext)
+		p := writeExternalFile(t, ext, "capture", "GIF89a\x01\x00")
+
+		results, err := ft.ImportPaths([]string{p}, "", ImportOptions{})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertImportSucceeded(t, results, 1)
+		if results[0].Path != "capture.gif" {
+			t.Errorf("got %s, want %s", results[0].Path, "capture.gif")
+		}
+	})
+
+	t.Run("import a folder and convert an obsidian vault", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testImportDir", "graph.json")
+		defer os.RemoveAll(dir)
+		createDirHelper(t, dir, "notes")
+		ext := createExternalDir(t)
+		defer os.RemoveAll(ext)
+		vault := filepath.Join(ext, "vault")
+		writeExternalFile(t, vault, ".obsidian/app.json", "{}")
+		writeExternalFile(t, vault, "Sol/oki.md", "---\ntags: sol\n---\n# Oki\nmeaty 5K")
+		writeExternalFile(t, vault, "img/setup.png", "\x89PNG\r\n\x1a\n")
+		writeExternalFile(t, vault, "board.canvas", `{
+			"nodes": [
+				{"id": "a", "type": "text", "text": "hello", "x": 0, "y": 0, "width": 100, "height": 50},
+				{"id": "b", "type": "file", "file": "img/setup.png", "x": 200, "y": 0, "width": 100, "height": 50}
+			],
+			"edges": [{"id": "e", "fromNode": "a", "fromSide": "right", "toNode": "b", "toSide": "left"}]
+		}`)
+		writeExternalFile(t, vault, "notes.txt", "unsupported")
+
+		results, err := ft.ImportPaths([]string{vault}, "notes", ImportOptions{ConvertFormats: true})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertImportSucceeded(t, results, 3)
+		assertFileExistence(t, dir, "notes", "vault", "Sol", "oki.json")
+		assertDirDoesNotExist(t, filepath.Join(dir, "notes", "vault", ".obsidian"))
+
+		b, err := os.ReadFile(filepath.Join(dir, "notes", "vault", "board.json"))
+		if err != nil {
+			t.Fatalf("couldn't read converted canvas: %v", err)
+		}
+
+		var g graph.Graph
+		err = json.Unmarshal(b, &g)
+		if err != nil {
+			t.Fatalf("the converted canvas is not a graph: %v", err)
+		}
+
+		if g.Nodes[1].Data.Image != "notes/vault/img/setup.png" {
+			t.Errorf("got %s, want %s", g.Nodes[1].Data.Image, "notes/vault/img/setup.png")
+		}
+
+		if g.Edges[0].SourceHandle != "aright" {
+			t.Errorf("got %s, want %s", g.Edges[0].SourceHandle, "aright")
+		}
+	})
+
+	t.Run("paths inside the lab are refused", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testImportInside", "graph.json")
+		defer os.RemoveAll(dir)
+
+		results, err := ft.ImportPaths([]string{filepath.Join(dir, "graph.json")}, "", ImportOptions{})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if results[0].Error != ErrImportInsideLab.Error() {
+			t.Errorf("got %s, want %v", results[0].Error, ErrImportInsideLab)
+		}
+	})
+
+	t.Run("parents of the lab are refused", func(t *testing.T) {
+		parent := createExternalDir(t)
+		defer os.RemoveAll(parent)
+		lab := filepath.Join(parent, "lab")
+		os.Mkdir(lab, os.ModePerm)
+		c := &config.AppConfig{ConfigFile: config.ConfigFile{LabPath: lab}}
+		ft := NewFileHandler(c, jobs.NewManager(c))
+
+		results, err := ft.ImportPaths([]string{parent}, "", ImportOptions{})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(results) != 1 || results[0].Error != ErrImportContainsLab.Error() {
+			t.Errorf("got %+v, want an %v error", results, ErrImportContainsLab)
+		}
+
+		assertDirDoesNotExist(t, filepath.Join(lab, filepath.Base(parent)))
+	})
+}
+
+func assertDirDoesNotExist(t testing.TB, absPath string) {
+	t.Helper()
+
+	if doesFileExist(absPath) {
+		t.Errorf("%s should not exist", absPath)
+	}
+}
@@ -3,6 +3,9 @@ package file_handler
 import (
 	"encoding/base64"
 	"errors"
+	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/fsutil"
+	"flow-poc/backend/filesystem/node"
 	"mime"
 	"os"
 	"path/filepath"
@@ -31,43 +34,50 @@ func (ft *FileHandler) OpenMedia(path string) (string, error) {
 		return "", err
 	}
 
-	m := mime.TypeByExtension(filepath.Ext(p))
+	var m string
+	if f, ok := node.FormatFromExtension(filepath.Ext(p)); ok {
+		m = f.MimeType()
+	} else {
+		m = mime.TypeByExtension(filepath.Ext(p))
+	}
 
 	s := base64.StdEncoding.EncodeToString(b)
 	return "data:" + m + ";base64," + s, nil
 }
 
+// Saves a media next to the graph at pathToFile, or in the media folder of the settings when
+// one is set. An empty fileName gets a name built from the media type and the current time
 func (fh *FileHandler) SaveMedia(fileName, pathToFile, mimetype, base64File string) (string, error) {
 	p := filepath.Dir(pathToFile)
-	b, err := fileToBytes(base64File, mimetype)
-	if err != nil {
-		return "", err
-	}
-
-	if fileName == "" {
-		fn, err := fh.createFileName(p, mimetype)
+	if folder := fh.Cfg.GetSettings().Media.MediaFolder; folder != "" {
+		p = folder
+		err := os.MkdirAll(filepath.Join(fh.GetLabPath(), folder), os.ModePerm)
 		if err != nil {
 			return "", err
 		}
+	}
 
-		fileName = fn
-	} else {
-		_, ext, err := getTypeAndExtensionWithMime(mimetype)
-		if err != nil {
-			return "", err
-		}
+	b, err := fileToBytes(base64File, mimetype)
+	if err != nil {
+		return "", err
+	}
 
-		fileName = filepath.Join(fh.GetLabPath(), p, fileName) + ext
+	mediaType, ext, err := getTypeAndExtensionWithMime(mimetype)
+	if errors.Is(err, ErrMediaNotSupported) {
+		mediaType, ext, err = getTypeAndExtensionWithContent(b)
 	}
 
-	var f *os.File
+	if err != nil {
+		return "", err
+	}
 
-	if doesFileExist(fileName) {
-		f, _, err = createNonDuplicateFile(fileName)
+	if fileName == "" {
+		fileName = fh.createFileName(p, mediaType, ext)
 	} else {
-		f, err = os.Create(fileName)
+		fileName = filepath.Join(fh.GetLabPath(), p, fileName) + ext
 	}
 
+	f, err := os.Create(fsutil.NonDuplicatePath(fileName, false))
 	if err != nil {
 		return "", err
 	}
@@ -85,6 +95,17 @@ func (fh *FileHandler) SaveMedia(fileName, pathToFile, mimetype, base64File stri
 	return f.Name(), nil
 }
 
+// Creates the media folder of the settings, if any, so it shows up in the lab as soon as it's
+// chosen or another lab is opened
+func (fh *FileHandler) onConfigChange(c config.Change) error {
+	folder := c.Settings.Media.MediaFolder
+	if folder == "" || c.Lab == "" || (!c.LabSwitched() && folder == c.PreviousSettings.Media.MediaFolder) {
+		return nil
+	}
+
+	return os.MkdirAll(filepath.Join(c.Lab, folder), os.ModePerm)
+}
+
 func fileToBytes(b64File, mimetype string) ([]byte, error) {
 	s, _ := strings.CutPrefix(b64File, "data:"+mimetype+";base64,")
 	b := make([]byte, base64.StdEncoding.DecodedLen(len(s)))
@@ -97,50 +118,45 @@ func fileToBytes(b64File, mimetype string) ([]byte, error) {
 		return nil, ErrNothingRead
 	}
 
-	return b, nil
+	return b[:n], nil
 }
 
-func (fh *FileHandler) createFileName(pathFromLabRoot, mimetype string) (string, error) {
-	var filename string
+func (fh *FileHandler) createFileName(pathFromLabRoot, mediaType, ext string) string {
 	t := time.Now().Format("20060102150405")
+	filename := mediaType + t + ext
 
-	mediaType, ext, err := getTypeAndExtensionWithMime(mimetype)
-	if err != nil {
-		return "", err
+	return filepath.Join(fh.GetLabPath(), pathFromLabRoot, filename)
+}
+
+// Uses the format registry to find the prefix of generated media names and the extension
+// matching the given MIME type. Only images and videos are accepted
+func getTypeAndExtensionWithMime(mimetype string) (string, string, error) {
+	f, ok := node.FormatFromMime(mimetype)
+	if !ok {
+		return "", "", ErrMediaNotSupported
 	}
 
-	filename = mediaType + t + ext
+	return mediaTypePrefix(f)
+}
+
+// Same as getTypeAndExtensionWithMime but looks for a known signature in the
+// media's content. Used when the client sends a MIME type the registry doesn't know
+func getTypeAndExtensionWithContent(b []byte) (string, string, error) {
+	f, ok := node.FormatFromContent(b)
+	if !ok {
+		return "", "", ErrMediaNotSupported
+	}
 
-	return filepath.Join(fh.GetLabPath(), pathFromLabRoot, filename), nil
+	return mediaTypePrefix(f)
 }
 
-func getTypeAndExtensionWithMime(mimetype string) (string, string, error) {
-	var mediaType, extension string
-
-	mediaType = "Image "
-	switch mimetype {
-	case "image/jpeg":
-		extension = ".jpeg"
-	case "image/png":
-		extension = ".png"
-	case "image/gif":
-		extension = ".gif"
-	case "image/webp":
-		extension = ".webp"
-	case "image/bmp":
-		extension = ".bmp"
-	case "video/mp4":
-		mediaType = "Video "
-		extension = ".mp4"
-	case "video/mpeg":
-		mediaType = "Video "
-		extension = ".mpeg"
-	case "video/webm":
-		mediaType = "Video "
-		extension = ".webm"
+func mediaTypePrefix(f node.Format) (string, string, error) {
+	switch f.FileType {
+	case node.IMAGE:
+		return "Image ", f.Extension(), nil
+	case node.VIDEO:
+		return "Video ", f.Extension(), nil
 	default:
 		return "", "", ErrMediaNotSupported
 	}
-
-	return mediaType, extension, nil
 }
@@ -3,6 +3,7 @@ package file_handler_test
 import (
 	"flow-poc/backend/config"
 	"flow-poc/backend/filesystem/file_handler"
+	"flow-poc/backend/jobs"
 	"os"
 	"path/filepath"
 	"testing"
@@ -18,11 +19,12 @@ func createTempDir(t testing.TB, dirName string) (string, *file_handler.FileHand
 		t.Fatalf("an error occured while creating temporary directory: %v", err)
 	}
 
-	fh := file_handler.NewFileHandler(&config.AppConfig{
+	c := &config.AppConfig{
 		ConfigFile: config.ConfigFile{
 			LabPath: dir,
 		},
-	})
+	}
+	fh := file_handler.NewFileHandler(c, jobs.NewManager(c))
 
 	b, err := os.ReadFile("./testFiles/pngImage.txt")
 	if err != nil {
@@ -68,6 +70,22 @@ func TestSaveMedia(t *testing.T) {
 		}
 	})
 
+	t.Run("save in the media folder of the settings", func(t *testing.T) {
+		dir, ft := createTempDir(t, "saveMediaFolder")
+		defer os.RemoveAll(dir)
+		s := openPngImageFile(t)
+		ft.Cfg.ConfigFile.Settings.Media.MediaFolder = "Médias"
+
+		path, err := ft.SaveMedia("", "Graphs/punish.json", "image/png", s)
+		if err != nil {
+			t.Fatalf("got an unexpected error: %v", err)
+		}
+
+		if filepath.Dir(path) != filepath.Join(dir, "Médias") {
+			t.Errorf("got %s, want a file in the media folder", path)
+		}
+	})
+
 	// TODO: Tester les autres formats de fichiers
 }
 
@@ -1,8 +1,10 @@
 package file_handler
 
 import (
+	"flow-poc/backend/filesystem/fsutil"
 	"io"
 	"os"
+	"path/filepath"
 )
 
 // Move file utility function.
@@ -14,7 +16,7 @@ func moveFile(oldPath, newPath string) (string, error) {
 	if !doesFileExist(newPath) {
 		err := os.Rename(oldPath, newPath)
 		if err != nil {
-			return "", nil
+			return "", err
 		}
 
 		info, err := os.Stat(newPath)
@@ -29,7 +31,7 @@ func moveFile(oldPath, newPath string) (string, error) {
 		return "", err
 	}
 
-	newFile, name, err := createNonDuplicateFile(newPath)
+	newFile, err := os.Create(fsutil.NonDuplicatePath(newPath, false))
 	if err != nil {
 		return "", err
 	}
@@ -42,7 +44,7 @@ func moveFile(oldPath, newPath string) (string, error) {
 
 	err = oldFile.Close()
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 
 	// We delete the old file
@@ -51,5 +53,5 @@ func moveFile(oldPath, newPath string) (string, error) {
 		return "", err
 	}
 
-	return name, err
+	return filepath.Base(newFile.Name()), err
 }
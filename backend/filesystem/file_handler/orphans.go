@@ -0,0 +1,358 @@
+package file_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"flow-poc/backend/clip"
+	"flow-poc/backend/filesystem/fsutil"
+	"flow-poc/backend/filesystem/labignore"
+	"flow-poc/backend/filesystem/node"
+	"flow-poc/backend/graph"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// Default directory, relative to the lab's root, where orphaned medias are moved for review
+	orphanReviewDirName = "Médias orphelins"
+	// Empty file marking a directory orphaned medias were moved into, so a review directory
+	// chosen by the user is skipped as well
+	orphanReviewMarker = ".orphan-review"
+)
+
+var (
+	ErrMediaNotOrphaned    = errors.New("media is still referenced by a graph")
+	ErrReviewDirOutsideLab = errors.New("the review directory must be inside the lab")
+)
+
+// An orphaned media is an image or a video of the lab that no graph references anymore
+type OrphanedMedia struct {
+	// Path to the media starting from the lab root, using forward slashes
+	Path      string        `json:"path"`
+	FileType  node.FileType `json:"fileType"`
+	Size      int64         `json:"size"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+	// Number of full days since the media was last modified
+	AgeInDays int `json:"ageInDays"`
+}
+
+// Walks through the whole lab and returns every image and video that isn't referenced
+// by any graph or clip. A media is referenced if a graph node points to it through its image
+// property, if its path from the lab root appears in a node's text or an edge's label or if
+// it's the source of a clip
+func (fh *FileHandler) FindOrphanedMedia() ([]OrphanedMedia, error) {
+	medias, graphs, clips, err := fh.listMediasAndReferrers()
+	if err != nil {
+		return nil, &FindOrphanedMediaError{err}
+	}
+
+	refs, texts, err := fh.collectGraphReferences(graphs)
+	if err != nil {
+		return nil, &FindOrphanedMediaError{err}
+	}
+
+	err = fh.collectClipReferences(clips, refs)
+	if err != nil {
+		return nil, &FindOrphanedMediaError{err}
+	}
+
+	now := time.Now()
+	orphans := make([]OrphanedMedia, 0)
+	for _, m := range medias {
+		if isMediaReferenced(m.Path, refs, texts) {
+			continue
+		}
+
+		m.AgeInDays = int(now.Sub(m.UpdatedAt).Hours() / 24)
+		orphans = append(orphans, m)
+	}
+
+	return orphans, nil
+}
+
+// Moves every given media into a review directory so the user can check them before deleting them.
+// If reviewDirFromLabRoot is empty, the default review directory is used. Each path must point to
+// a media that is still orphaned. The review directory is marked so its medias aren't reported
+// by later scans. Returns the new paths of the medias starting from the lab root
+func (fh *FileHandler) MoveOrphanedMedia(pathsFromLabRoot []string, reviewDirFromLabRoot string) ([]string, error) {
+	if err := fh.checkOrphans(pathsFromLabRoot); err != nil {
+		return nil, err
+	}
+
+	if reviewDirFromLabRoot == "" {
+		reviewDirFromLabRoot = orphanReviewDirName
+	}
+
+	labPath := fh.GetLabPath()
+	reviewDir := filepath.Join(labPath, reviewDirFromLabRoot)
+	// The review directory comes from the frontend
+	if !fsutil.IsInside(labPath, reviewDir) {
+		return nil, &OrphanedMediaError{reviewDirFromLabRoot, ErrReviewDirOutsideLab}
+	}
+
+	err := os.MkdirAll(reviewDir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(filepath.Join(reviewDir, orphanReviewMarker), nil, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	newPaths := make([]string, 0, len(pathsFromLabRoot))
+	for _, p := range pathsFromLabRoot {
+		oldPath := filepath.Join(labPath, p)
+		name, err := moveFile(oldPath, filepath.Join(reviewDir, filepath.Base(p)))
+		if err != nil {
+			return newPaths, err
+		}
+
+		// Sidecars follow their media
+		err = node.MoveSidecars(oldPath, filepath.Join(reviewDir, name))
+		if err != nil {
+			return newPaths, err
+		}
+
+		newPath := filepath.ToSlash(filepath.Join(reviewDirFromLabRoot, name))
+		fh.RecentFiles.RemoveRecent(p)
+		fh.Favorites.ReconcilePaths(filepath.ToSlash(p), newPath)
+		newPaths = append(newPaths, newPath)
+	}
+
+	return newPaths, nil
+}
+
+// Deletes every given media from the user's machine. Each path must point to
+// a media that is still orphaned. Deletion goes on even if one of the medias
+// couldn't be removed and every error is returned at the end
+func (fh *FileHandler) DeleteOrphanedMedia(pathsFromLabRoot []string) error {
+	if err := fh.checkOrphans(pathsFromLabRoot); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, p := range pathsFromLabRoot {
+		if err := fh.DeleteFile(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Makes sure every path given points to a media that isn't referenced anywhere.
+// Orphans are computed again since graphs may have changed since the last scan
+func (fh *FileHandler) checkOrphans(pathsFromLabRoot []string) error {
+	orphans, err := fh.FindOrphanedMedia()
+	if err != nil {
+		return err
+	}
+
+	orphanPaths := make(map[string]struct{}, len(orphans))
+	for _, o := range orphans {
+		orphanPaths[o.Path] = struct{}{}
+	}
+
+	for _, p := range pathsFromLabRoot {
+		if _, ok := orphanPaths[filepath.ToSlash(p)]; !ok {
+			return &OrphanedMediaError{p, ErrMediaNotOrphaned}
+		}
+	}
+
+	return nil
+}
+
+// Walks the lab and sorts its files between medias, graphs and clips.
+// Graphs and clips are returned as absolute paths
+func (fh *FileHandler) listMediasAndReferrers() ([]OrphanedMedia, []string, []string, error) {
+	labPath := fh.GetLabPath()
+	medias := make([]OrphanedMedia, 0)
+	graphs := make([]string, 0)
+	clips := make([]string, 0)
+
+	// Same rules as the file tree
+	ignore, err := labignore.ForLab(labPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = filepath.WalkDir(labPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if ignore.Ignores(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			// Medias already moved for review aren't reported again
+			if path == filepath.Join(labPath, orphanReviewDirName) || fsutil.Exists(filepath.Join(path, orphanReviewMarker)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		fileType := node.DetectFileTypeFromPath(path)
+		switch fileType {
+		case node.GRAPH:
+			graphs = append(graphs, path)
+		case node.CLIP:
+			clips = append(clips, path)
+		case node.IMAGE, node.VIDEO:
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(labPath, path)
+			if err != nil {
+				return err
+			}
+
+			medias = append(medias, OrphanedMedia{
+				Path:      filepath.ToSlash(rel),
+				FileType:  fileType,
+				Size:      info.Size(),
+				UpdatedAt: info.ModTime(),
+			})
+		}
+
+		return nil
+	})
+
+	return medias, graphs, clips, err
+}
+
+// Reads every graph and returns the set of medias referenced by the nodes' image property
+// as paths from the lab root, alongside every piece of free text found in the graphs.
+// Files that can't be parsed as graphs are skipped
+func (fh *FileHandler) collectGraphReferences(graphPaths []string) (map[string]struct{}, []string, error) {
+	labPath := fh.GetLabPath()
+	refs := make(map[string]struct{})
+	texts := make([]string, 0)
+
+	for _, p := range graphPaths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var g graph.Graph
+		if err := json.Unmarshal(b, &g); err != nil {
+			continue
+		}
+
+		for _, n := range g.Nodes {
+			if n.Data.Image != "" {
+				refs[mediaRefFromLabRoot(labPath, n.Data.Image)] = struct{}{}
+			}
+
+			if n.Data.Text != "" {
+				texts = append(texts, filepath.ToSlash(n.Data.Text))
+			}
+		}
+
+		for _, e := range g.Edges {
+			if e.Label != "" {
+				texts = append(texts, filepath.ToSlash(e.Label))
+			}
+		}
+	}
+
+	return refs, texts, nil
+}
+
+// Adds the source video of every clip to the set of referenced medias.
+// Files that can't be parsed as clips are skipped
+func (fh *FileHandler) collectClipReferences(clipPaths []string, refs map[string]struct{}) error {
+	for _, p := range clipPaths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		var c clip.Clip
+		if err := json.Unmarshal(b, &c); err != nil {
+			continue
+		}
+
+		if c.Source != "" {
+			refs[mediaRefFromLabRoot(fh.GetLabPath(), c.Source)] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// Medias can be referenced either by an absolute path or by a path relative to the lab's root.
+// This function normalizes both to a slashed path starting from the lab root
+func mediaRefFromLabRoot(labPath, ref string) string {
+	if filepath.IsAbs(ref) {
+		if rel, err := filepath.Rel(labPath, ref); err == nil {
+			ref = rel
+		}
+	}
+
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(ref)), "/")
+}
+
+func isMediaReferenced(pathFromLabRoot string, refs map[string]struct{}, texts []string) bool {
+	if _, ok := refs[pathFromLabRoot]; ok {
+		return true
+	}
+
+	for _, t := range texts {
+		if containsPath(t, pathFromLabRoot) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reports whether p appears in the text as a whole path: "a.png" is found in "voir a.png."
+// but not in "data.png", "Sol/a.png" or "a.png.bak"
+func containsPath(text, p string) bool {
+	offset := 0
+	for {
+		i := strings.Index(text[offset:], p)
+		if i == -1 {
+			return false
+		}
+
+		i += offset
+		end := i + len(p)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		if (i == 0 || !isPathRune(before)) && !continuesPath(text[end:]) {
+			return true
+		}
+
+		offset = i + 1
+	}
+}
+
+// Reports whether the text starts with the rest of a path. A dot only continues
+// a path if it's followed by another character of a path, like an extension
+func continuesPath(text string) bool {
+	r, size := utf8.DecodeRuneInString(text)
+	if r == '.' {
+		next, _ := utf8.DecodeRuneInString(text[size:])
+		return isPathRune(next)
+	}
+
+	return isPathRune(r)
+}
+
+func isPathRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("/\\._-", r)
+}
@@ -0,0 +1,261 @@
+package file_handler
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"flow-poc/backend/graph"
+)
+
+// Creates a graph referencing the given media through a node's image property
+func createGraphWithImage(t testing.TB, ft *FileHandler, fileName, image string) {
+	t.Helper()
+
+	_, err := ft.CreateFile(fileName)
+	if err != nil {
+		t.Fatalf("couldn't create graph: %v", err)
+	}
+
+	g := graph.GetInitGraph()
+	g.Nodes[0].Data.Image = image
+	err = ft.SaveFile(fileName, g)
+	if err != nil {
+		t.Fatalf("couldn't save graph: %v", err)
+	}
+}
+
+func TestFindOrphanedMedia(t *testing.T) {
+	t.Run("medias referenced by absolute or relative paths are not orphans", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testOrphans", "graph.json")
+		defer os.RemoveAll(dir)
+		createDirHelper(t, dir, "medias")
+		createFileHelper(t, dir, "medias/used.png")
+		createFileHelper(t, dir, "relative.webm")
+		createFileHelper(t, dir, "orphan.png")
+
+		createGraphWithImage(t, ft, "graph.json", filepath.Join(dir, "medias", "used.png"))
+		createGraphWithImage(t, ft, "other.json", "relative.webm")
+
+		orphans, err := ft.FindOrphanedMedia()
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(orphans) != 1 {
+			t.Fatalf("want 1 orphan, got %d: %v", len(orphans), orphans)
+		}
+
+		if orphans[0].Path != "orphan.png" {
+			t.Errorf("wrong orphan found, got %s, want %s", orphans[0].Path, "orphan.png")
+		}
+	})
+
+	t.Run("sources of clips are not orphans", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testOrphansClip", "graph.json")
+		defer os.RemoveAll(dir)
+		createFileHelper(t, dir, "session.webm")
+
+		err := os.WriteFile(filepath.Join(dir, "punish.clip"), []byte(`{"source": "session.webm", "in": 1, "out": 2}`), 0644)
+		if err != nil {
+			t.Fatalf("couldn't write clip: %v", err)
+		}
+
+		orphans, err := ft.FindOrphanedMedia()
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(orphans) != 0 {
+			t.Errorf("want no orphan, got %v", orphans)
+		}
+	})
+
+	t.Run("medias mentioned in a node's text are not orphans", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testOrphansText", "graph.json")
+		defer os.RemoveAll(dir)
+		createFileHelper(t, dir, "mentioned.mp4")
+
+		g := graph.GetInitGraph()
+		g.Nodes[0].Data.Text = "voir mentioned.mp4 pour le punish"
+		err := ft.SaveFile("graph.json", g)
+		if err != nil {
+			t.Fatalf("couldn't save graph: %v", err)
+		}
+
+		orphans, err := ft.FindOrphanedMedia()
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(orphans) != 0 {
+			t.Errorf("want no orphan, got %v", orphans)
+		}
+	})
+
+	t.Run("a mention inside a longer path doesn't count", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testOrphansLongerText", "graph.json")
+		defer os.RemoveAll(dir)
+		createFileHelper(t, dir, "a.png")
+		createFileHelper(t, dir, "b.png")
+
+		g := graph.GetInitGraph()
+		g.Nodes[0].Data.Text = "voir data.png, Sol/a.png ou a.png.bak puis b.png."
+		err := ft.SaveFile("graph.json", g)
+		if err != nil {
+			t.Fatalf("couldn't save graph: %v", err)
+		}
+
+		orphans, err := ft.FindOrphanedMedia()
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(orphans) != 1 || orphans[0].Path != "a.png" {
+			t.Errorf("want only a.png, got %v", orphans)
+		}
+	})
+
+	t.Run("the rules of the .labignore apply", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testOrphansIgnore", "graph.json")
+		defer os.RemoveAll(dir)
+		createDirHelper(t, dir, "archives")
+		createDirHelper(t, dir, ".captures")
+		createFileHelper(t, dir, "archives/old.png")
+		createFileHelper(t, dir, ".captures/setup.png")
+
+		err := os.WriteFile(filepath.Join(dir, ".labignore"), []byte("archives/\n!.captures\n"), 0644)
+		if err != nil {
+			t.Fatalf("couldn't write .labignore: %v", err)
+		}
+
+		orphans, err := ft.FindOrphanedMedia()
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(orphans) != 1 || orphans[0].Path != ".captures/setup.png" {
+			t.Errorf("want only .captures/setup.png, got %v", orphans)
+		}
+	})
+}
+
+func TestCleanUpOrphanedMedia(t *testing.T) {
+	t.Run("move orphans to the review directory", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testMoveOrphans", "graph.json")
+		defer os.RemoveAll(dir)
+		createFileHelper(t, dir, "orphan.png")
+
+		newPaths, err := ft.MoveOrphanedMedia([]string{"orphan.png"}, "")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(newPaths) != 1 {
+			t.Fatalf("want 1 moved media, got %d", len(newPaths))
+		}
+
+		assertFileExistence(t, dir, orphanReviewDirName, "orphan.png")
+
+		orphans, err := ft.FindOrphanedMedia()
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(orphans) != 0 {
+			t.Errorf("medias under review shouldn't be reported again, got %v", orphans)
+		}
+	})
+
+	t.Run("pinned orphans stay pinned once moved", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testMoveOrphansFavorite", "graph.json")
+		defer os.RemoveAll(dir)
+		createFileHelper(t, dir, "orphan.png")
+
+		err := ft.Favorites.AddFavorite("orphan.png", "")
+		if err != nil {
+			t.Fatalf("couldn't pin the orphan: %v", err)
+		}
+
+		newPaths, err := ft.MoveOrphanedMedia([]string{"orphan.png"}, "")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		sections, err := ft.Favorites.GetFavorites()
+		if err != nil {
+			t.Fatalf("couldn't read favorites: %v", err)
+		}
+
+		if got := sections[0].Paths; len(got) != 1 || got[0] != newPaths[0] {
+			t.Errorf("got %v, want [%s]", got, newPaths[0])
+		}
+	})
+
+	t.Run("medias in a custom review directory are not reported again", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testMoveOrphansCustom", "graph.json")
+		defer os.RemoveAll(dir)
+		createFileHelper(t, dir, "orphan.png")
+
+		_, err := ft.MoveOrphanedMedia([]string{"orphan.png"}, "Sol/review")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		assertFileExistence(t, dir, "Sol", "review", "orphan.png")
+
+		orphans, err := ft.FindOrphanedMedia()
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(orphans) != 0 {
+			t.Errorf("medias under review shouldn't be reported again, got %v", orphans)
+		}
+	})
+
+	t.Run("refuse a review directory outside of the lab", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testMoveOrphansOutside", "graph.json")
+		defer os.RemoveAll(dir)
+		createFileHelper(t, dir, "orphan.png")
+
+		for _, reviewDir := range []string{"..", "../review", "Sol/../..", "."} {
+			_, err := ft.MoveOrphanedMedia([]string{"orphan.png"}, reviewDir)
+			if !errors.Is(err, ErrReviewDirOutsideLab) {
+				t.Errorf("%s: got %v, want %v", reviewDir, err, ErrReviewDirOutsideLab)
+			}
+		}
+
+		assertFileExistence(t, dir, "orphan.png")
+	})
+
+	t.Run("delete orphans", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testDeleteOrphans", "graph.json")
+		defer os.RemoveAll(dir)
+		createFileHelper(t, dir, "orphan.png")
+
+		err := ft.DeleteOrphanedMedia([]string{"orphan.png"})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if doesFileExist(filepath.Join(dir, "orphan.png")) {
+			t.Error("the orphan was not deleted")
+		}
+	})
+
+	t.Run("refuse to delete a referenced media", func(t *testing.T) {
+		dir, ft := createTempDir(t, "testDeleteUsed", "graph.json")
+		defer os.RemoveAll(dir)
+		createFileHelper(t, dir, "used.png")
+		createGraphWithImage(t, ft, "graph.json", "used.png")
+
+		err := ft.DeleteOrphanedMedia([]string{"used.png"})
+		if !errors.Is(err, ErrMediaNotOrphaned) {
+			t.Errorf("got %v, want %v", err, ErrMediaNotOrphaned)
+		}
+
+		assertFileExistence(t, dir, "used.png")
+	})
+}
@@ -0,0 +1,230 @@
+package file_handler
+
+import (
+	"flow-poc/backend/filesystem/labignore"
+	"flow-poc/backend/filesystem/node"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+type TreeOptions struct {
+	// Directory the tree starts from, starting from the lab root. Empty for the whole lab
+	Path string `json:"path"`
+	// Number of levels read below Path. 0 or less reads everything
+	Depth int `json:"depth"`
+	// Order of the children of each directory. Directories always come first
+	Sort       node.SortMode `json:"sort"`
+	Descending bool          `json:"descending"`
+	// Only files of these types are returned, every file is returned if empty.
+	// Directories are always returned
+	FileTypes []node.FileType `json:"fileTypes"`
+}
+
+// Changes of the tree since the previous call to GetLabTreeChanges
+type TreeDiff struct {
+	// True if there was nothing to compare to. Upserted then holds the whole tree
+	Full bool `json:"full"`
+	// Elements that appeared or were modified, without their children
+	Upserted []*node.TreeNode `json:"upserted"`
+	// Paths of the elements that disappeared. The content of a removed directory isn't listed
+	Removed []string `json:"removed"`
+}
+
+// Content of every directory as it was during the last call to GetLabTreeChanges
+type treeSnapshot struct {
+	mu sync.Mutex
+	// Paths of the snapshot are relative to this lab
+	labPath string
+	opts    TreeOptions
+	// Changes of the .labignore don't update the modification time of directories
+	ignore *labignore.Matcher
+	dirs   map[string]dirSnapshot
+}
+
+type dirSnapshot struct {
+	modTime time.Time
+	// Children of the directory that passed the filters, by path from the lab root
+	entries map[string]node.Node
+}
+
+// Reads the lab's directories recursively and returns them as a tree
+func (fh *FileHandler) GetLabTree(opts TreeOptions) (*node.TreeNode, error) {
+	root := &node.TreeNode{
+		Node: node.Node{
+			Name: filepath.Base(filepath.Join(fh.GetLabPath(), opts.Path)),
+			Type: node.DIR,
+		},
+		Path: slashed(opts.Path),
+	}
+
+	children, err := fh.readTree(root.Path, opts.Depth, opts)
+	if err != nil {
+		return nil, &GetSubDirAndFilesError{err}
+	}
+
+	root.Children = children
+	return root, nil
+}
+
+func (fh *FileHandler) readTree(dirFromLabRoot string, depth int, opts TreeOptions) ([]*node.TreeNode, error) {
+	nodes, err := fh.readDirNodes(dirFromLabRoot, opts.FileTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	node.SortNodes(nodes, opts.Sort, opts.Descending)
+
+	tree := make([]*node.TreeNode, 0, len(nodes))
+	for _, n := range nodes {
+		tn := &node.TreeNode{
+			Node: *n,
+			Path: path.Join(dirFromLabRoot, n.Name+n.Extension),
+		}
+
+		if n.Type == node.DIR && (depth <= 0 || depth > 1) {
+			tn.Children, err = fh.readTree(tn.Path, depth-1, opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		tree = append(tree, tn)
+	}
+
+	return tree, nil
+}
+
+// Returns what changed in the tree since the previous call. Only directories whose modification
+// time changed are read again, so files modified in place are not reported: the watcher
+// already sends an event for them. Calling it with different options starts over with a full tree
+func (fh *FileHandler) GetLabTreeChanges(opts TreeOptions) (TreeDiff, error) {
+	fh.tree.mu.Lock()
+	defer fh.tree.mu.Unlock()
+
+	prev := fh.tree.dirs
+	diff := TreeDiff{
+		Upserted: make([]*node.TreeNode, 0),
+		Removed:  make([]string, 0),
+	}
+
+	ignore, err := labignore.ForLab(fh.GetLabPath())
+	if err != nil {
+		return TreeDiff{}, &GetSubDirAndFilesError{err}
+	}
+
+	if prev == nil || fh.tree.labPath != fh.GetLabPath() || !sameTreeScope(fh.tree.opts, opts) || fh.tree.ignore != ignore {
+		prev = make(map[string]dirSnapshot)
+		diff.Full = true
+	}
+
+	next := make(map[string]dirSnapshot, len(prev))
+	err = fh.diffDir(slashed(opts.Path), opts.Depth, opts, prev, next, &diff)
+	if err != nil {
+		return TreeDiff{}, &GetSubDirAndFilesError{err}
+	}
+
+	fh.tree.labPath = fh.GetLabPath()
+	fh.tree.opts = opts
+	fh.tree.ignore = ignore
+	fh.tree.dirs = next
+
+	sort.Slice(diff.Upserted, func(i, j int) bool {
+		return diff.Upserted[i].Path < diff.Upserted[j].Path
+	})
+	sort.Strings(diff.Removed)
+
+	return diff, nil
+}
+
+func (fh *FileHandler) diffDir(dirFromLabRoot string, depth int, opts TreeOptions, prev, next map[string]dirSnapshot, diff *TreeDiff) error {
+	info, err := os.Stat(filepath.Join(fh.GetLabPath(), dirFromLabRoot))
+	if err != nil {
+		return err
+	}
+
+	old, known := prev[dirFromLabRoot]
+	snap := dirSnapshot{info.ModTime(), old.entries}
+
+	if !known || !old.modTime.Equal(info.ModTime()) {
+		nodes, err := fh.readDirNodes(dirFromLabRoot, opts.FileTypes)
+		if err != nil {
+			return err
+		}
+
+		snap.entries = make(map[string]node.Node, len(nodes))
+		for _, n := range nodes {
+			p := path.Join(dirFromLabRoot, n.Name+n.Extension)
+			snap.entries[p] = *n
+
+			if o, ok := old.entries[p]; !ok || o.Type != n.Type || !o.UpdatedAt.Equal(n.UpdatedAt) || o.Size != n.Size {
+				diff.Upserted = append(diff.Upserted, &node.TreeNode{Node: *n, Path: p})
+			}
+		}
+
+		for p := range old.entries {
+			if _, ok := snap.entries[p]; !ok {
+				diff.Removed = append(diff.Removed, p)
+			}
+		}
+	}
+
+	next[dirFromLabRoot] = snap
+
+	if depth > 0 && depth <= 1 {
+		return nil
+	}
+
+	for p, n := range snap.entries {
+		if n.Type != node.DIR {
+			continue
+		}
+
+		if err := fh.diffDir(p, depth-1, opts, prev, next, diff); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reads a directory and keeps the directories and the files matching the given types
+func (fh *FileHandler) readDirNodes(dirFromLabRoot string, fileTypes []node.FileType) (node.Nodes, error) {
+	dirPath := filepath.Join(fh.GetLabPath(), dirFromLabRoot)
+	entries, err := fh.readLabDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := node.CreateNodesFromDirEntries(dirPath, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fileTypes) == 0 {
+		return nodes, nil
+	}
+
+	return slices.DeleteFunc(nodes, func(n *node.Node) bool {
+		return n.Type == node.FILE && !slices.Contains(fileTypes, n.FileType)
+	}), nil
+}
+
+// Diffs can only be computed between two reads of the same part of the lab
+func sameTreeScope(a, b TreeOptions) bool {
+	return slashed(a.Path) == slashed(b.Path) && a.Depth == b.Depth && slices.Equal(a.FileTypes, b.FileTypes)
+}
+
+// Turns a path from the lab root into the form used by the frontend
+func slashed(pathFromLabRoot string) string {
+	p := path.Clean("/" + filepath.ToSlash(pathFromLabRoot))
+	if p == "/" {
+		return ""
+	}
+
+	return p[1:]
+}
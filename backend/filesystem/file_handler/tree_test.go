@@ -0,0 +1,168 @@
+package file_handler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"flow-poc/backend/filesystem/node"
+)
+
+func treeNames(nodes []*node.TreeNode) []string {
+	names := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		names = append(names, n.Name+n.Extension)
+	}
+
+	return names
+}
+
+func TestGetLabTree(t *testing.T) {
+	dir, fh := createTempDir(t, "testTree", "b.json")
+	defer os.RemoveAll(dir)
+	createDirHelper(t, dir, "Sol/Combos")
+	createFileHelper(t, dir, "a.png")
+	createFileHelper(t, dir, "Sol/Combos/bnb.json")
+	os.WriteFile(filepath.Join(dir, "big.webm"), make([]byte, 100), 0644)
+
+	t.Run("read the whole lab", func(t *testing.T) {
+		tree, err := fh.GetLabTree(TreeOptions{})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		got := treeNames(tree.Children)
+		want := []string{"Sol", "a.png", "b.json", "big.webm"}
+		if len(got) != len(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+
+		bnb := tree.Children[0].Children[0].Children[0]
+		if bnb.Path != "Sol/Combos/bnb.json" {
+			t.Errorf("wrong path, got %s", bnb.Path)
+		}
+	})
+
+	t.Run("stop at the given depth", func(t *testing.T) {
+		tree, err := fh.GetLabTree(TreeOptions{Depth: 1})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if tree.Children[0].Children != nil {
+			t.Errorf("Sol shouldn't have been read: %v", treeNames(tree.Children[0].Children))
+		}
+	})
+
+	t.Run("sort by size and keep only some file types", func(t *testing.T) {
+		tree, err := fh.GetLabTree(TreeOptions{
+			Sort:       node.BY_SIZE,
+			Descending: true,
+			FileTypes:  []node.FileType{node.VIDEO, node.IMAGE},
+		})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		got := treeNames(tree.Children)
+		if len(got) != 3 || got[0] != "Sol" || got[1] != "big.webm" || got[2] != "a.png" {
+			t.Errorf("got %v", got)
+		}
+
+		if len(tree.Children[0].Children[0].Children) != 0 {
+			t.Errorf("graphs should have been filtered out")
+		}
+	})
+}
+
+func TestGetLabTreeChanges(t *testing.T) {
+	dir, fh := createTempDir(t, "testTreeChanges", "graph.json")
+	defer os.RemoveAll(dir)
+	createDirHelper(t, dir, "Sol/Combos")
+	createDirHelper(t, dir, "Ky")
+	createFileHelper(t, dir, "Sol/Combos/bnb.json")
+
+	diff, err := fh.GetLabTreeChanges(TreeOptions{})
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if !diff.Full || len(diff.Upserted) != 5 {
+		t.Fatalf("want a full diff of 5 elements, got %+v", diff)
+	}
+
+	diff, err = fh.GetLabTreeChanges(TreeOptions{})
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if diff.Full || len(diff.Upserted) != 0 || len(diff.Removed) != 0 {
+		t.Fatalf("nothing changed but got %+v", diff)
+	}
+
+	// Directory modification times must be different from the snapshot's
+	time.Sleep(10 * time.Millisecond)
+	createFileHelper(t, dir, "Sol/Combos/punish.json")
+	os.RemoveAll(filepath.Join(dir, "Ky"))
+
+	diff, err = fh.GetLabTreeChanges(TreeOptions{})
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if len(diff.Upserted) != 1 || diff.Upserted[0].Path != "Sol/Combos/punish.json" {
+		t.Errorf("wrong upserted elements: %v", diff.Upserted)
+	}
+
+	if len(diff.Removed) != 1 || diff.Removed[0] != "Ky" {
+		t.Errorf("wrong removed elements: %v", diff.Removed)
+	}
+
+	diff, err = fh.GetLabTreeChanges(TreeOptions{Depth: 1})
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if !diff.Full {
+		t.Errorf("changing the options should return a full diff")
+	}
+}
+
+func TestLabTreeHonorsLabignore(t *testing.T) {
+	dir, fh := createTempDir(t, "testTreeIgnore", "graph.json")
+	defer os.RemoveAll(dir)
+	createDirHelper(t, dir, "Brut")
+	createDirHelper(t, dir, ".labmonster")
+	createFileHelper(t, dir, "Brut/session.webm")
+	createFileHelper(t, dir, "capture.tmp")
+
+	err := os.WriteFile(filepath.Join(dir, ".labignore"), []byte("Brut/\n*.tmp\n"), 0644)
+	if err != nil {
+		t.Fatalf("couldn't write .labignore: %v", err)
+	}
+
+	tree, err := fh.GetLabTree(TreeOptions{})
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	got := treeNames(tree.Children)
+	if len(got) != 1 || got[0] != "graph.json" {
+		t.Errorf("ignored elements were listed: %v", got)
+	}
+
+	nodes, err := fh.GetSubDirAndFiles("")
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if len(nodes) != 1 {
+		t.Errorf("ignored elements were listed: %v", node.Nodes(nodes))
+	}
+}
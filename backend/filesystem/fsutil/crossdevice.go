@@ -0,0 +1,13 @@
+//go:build !windows
+
+package fsutil
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Reports whether a rename failed because the source and the destination are on different file systems
+func IsCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package fsutil
+
+import (
+	"errors"
+	"syscall"
+)
+
+// ERROR_NOT_SAME_DEVICE isn't exported by the syscall package
+const errNotSameDevice = syscall.Errno(17)
+
+// Reports whether a rename failed because the source and the destination are on different volumes
+func IsCrossDevice(err error) bool {
+	return errors.Is(err, errNotSameDevice)
+}
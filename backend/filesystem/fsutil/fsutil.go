@@ -0,0 +1,258 @@
+// This package holds the low level file system helpers shared by the handlers of the lab
+package fsutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func Exists(path string) bool {
+	_, err := os.Lstat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
+
+// Given an absolute path, returns it untouched if nothing exists there. Otherwise, a number is
+// appended to the name (before the extension for files) and incremented until a free path is found.
+// "setup.png" becomes "setup 1.png", ".labignore" becomes ".labignore 1" and the "Sol"
+// directory becomes "Sol 1". The path is not reserved: the caller must create the element right away
+func NonDuplicatePath(absPath string, isDir bool) string {
+	if !Exists(absPath) {
+		return absPath
+	}
+
+	ext := ""
+	// The leading dot of a dotfile is part of its name, not an extension
+	if !isDir && filepath.Ext(absPath) != filepath.Base(absPath) {
+		ext = filepath.Ext(absPath)
+	}
+	base := strings.TrimSuffix(absPath, ext)
+
+	for i := 1; ; i++ {
+		p := fmt.Sprintf("%s %d%s", base, i, ext)
+		if !Exists(p) {
+			return p
+		}
+	}
+}
+
+// Reports whether child is located inside parent. Both paths must be absolute
+func IsInside(parent, child string) bool {
+	rel, err := filepath.Rel(parent, child)
+	if err != nil {
+		return false
+	}
+
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Receives the progress of a copy. Both methods can be called from the goroutine running the copy
+type Progress interface {
+	// Called each time some bytes of the file at path are written
+	AddBytes(n int64, path string)
+	// Called once a file is entirely copied
+	ItemDone(path string)
+}
+
+// Returns the total size in bytes and the number of files of a file or a directory
+func Size(path string) (int64, int, error) {
+	var size int64
+	items := 0
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		size += info.Size()
+		items++
+		return nil
+	})
+
+	return size, items, err
+}
+
+// Copies a file's content, permissions and modification time. The destination must not exist
+func CopyFile(src, dst string) error {
+	return CopyFileContext(context.Background(), src, dst, nil)
+}
+
+// Same as CopyFile but stops as soon as ctx is done and reports its progress to p if it isn't nil.
+// The destination is removed if the copy doesn't complete
+func CopyFileContext(ctx context.Context, src, dst string, p Progress) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	// Reading the file directly lets io.Copy use the fastest copy the system offers
+	var r io.Reader = in
+	if p != nil || ctx.Done() != nil {
+		r = &progressReader{ctx, in, p, src}
+	}
+
+	_, err = io.Copy(out, r)
+	if cErr := out.Close(); err == nil {
+		err = cErr
+	}
+
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	err = os.Chtimes(dst, info.ModTime(), info.ModTime())
+	if err != nil {
+		return err
+	}
+
+	if p != nil {
+		p.ItemDone(src)
+	}
+
+	return nil
+}
+
+// Recursively copies a directory, keeping permissions and modification times.
+// The destination must not exist. If the copy fails, everything copied so far is removed.
+// The optional onFile callback is called after each copied file with its source and destination
+func CopyDir(src, dst string, onFile func(src, dst string) error) error {
+	return CopyDirContext(context.Background(), src, dst, onFile, nil)
+}
+
+// Same as CopyDir but stops as soon as ctx is done and reports its progress to p if it isn't nil.
+// dst must not exist. Everything copied so far is removed if the copy doesn't complete, an
+// existing dst is left untouched
+func CopyDirContext(ctx context.Context, src, dst string, onFile func(src, dst string) error, p Progress) error {
+	err := os.Mkdir(dst, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	err = copyDir(ctx, src, dst, onFile, p)
+	if err != nil {
+		os.RemoveAll(dst)
+	}
+
+	return err
+}
+
+func copyDir(ctx context.Context, src, dst string, onFile func(src, dst string) error, p Progress) error {
+	type dirTimes struct {
+		path string
+		info fs.FileInfo
+	}
+	dirs := make([]dirTimes, 0)
+
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			// The root was created by CopyDirContext
+			if rel == "." {
+				err = os.Chmod(target, info.Mode().Perm())
+			} else {
+				err = os.MkdirAll(target, info.Mode().Perm())
+			}
+
+			if err != nil {
+				return err
+			}
+
+			dirs = append(dirs, dirTimes{target, info})
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		err = CopyFileContext(ctx, path, target, p)
+		if err != nil {
+			return err
+		}
+
+		if onFile != nil {
+			return onFile(path, target)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Modification times of directories are set last since creating
+	// their content updates them
+	for i := len(dirs) - 1; i >= 0; i-- {
+		d := dirs[i]
+		if err := os.Chtimes(d.path, d.info.ModTime(), d.info.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reader that fails once its context is done and reports every read to a Progress
+type progressReader struct {
+	ctx  context.Context
+	r    io.Reader
+	p    Progress
+	path string
+}
+
+func (pr *progressReader) Read(b []byte) (int, error) {
+	if err := pr.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := pr.r.Read(b)
+	if n > 0 && pr.p != nil {
+		pr.p.AddBytes(int64(n), pr.path)
+	}
+
+	return n, err
+}
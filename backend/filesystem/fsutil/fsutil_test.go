@@ -0,0 +1,87 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNonDuplicatePath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"setup.png", ".labignore", "Sol", "notes"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	cases := []struct {
+		name  string
+		isDir bool
+		want  string
+	}{
+		{"free.png", false, "free.png"},
+		{"setup.png", false, "setup 1.png"},
+		{".labignore", false, ".labignore 1"},
+		{"Sol", true, "Sol 1"},
+		{"notes", false, "notes 1"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := filepath.Base(NonDuplicatePath(filepath.Join(dir, c.name), c.isDir))
+			if got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestCopyDir(t *testing.T) {
+	t.Run("copies the content of a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		os.MkdirAll(filepath.Join(dir, "Sol", "Combos"), os.ModePerm)
+		os.WriteFile(filepath.Join(dir, "Sol", "Combos", "bnb.json"), []byte("bnb"), 0644)
+
+		if err := CopyDir(filepath.Join(dir, "Sol"), filepath.Join(dir, "Sol 1"), nil); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		b, err := os.ReadFile(filepath.Join(dir, "Sol 1", "Combos", "bnb.json"))
+		if err != nil || string(b) != "bnb" {
+			t.Errorf("the content was not copied: %q, %v", b, err)
+		}
+	})
+
+	t.Run("an existing destination is left untouched", func(t *testing.T) {
+		dir := t.TempDir()
+		os.MkdirAll(filepath.Join(dir, "Sol"), os.ModePerm)
+		os.MkdirAll(filepath.Join(dir, "Ky"), os.ModePerm)
+		os.WriteFile(filepath.Join(dir, "Ky", "combo.json"), []byte("combo"), 0644)
+
+		err := CopyDir(filepath.Join(dir, "Sol"), filepath.Join(dir, "Ky"), nil)
+		if !errors.Is(err, os.ErrExist) {
+			t.Errorf("got %v, want %v", err, os.ErrExist)
+		}
+
+		if !Exists(filepath.Join(dir, "Ky", "combo.json")) {
+			t.Error("the content of the destination was removed")
+		}
+	})
+
+	t.Run("a failed copy removes what it created", func(t *testing.T) {
+		dir := t.TempDir()
+		os.MkdirAll(filepath.Join(dir, "Sol"), os.ModePerm)
+		os.WriteFile(filepath.Join(dir, "Sol", "combo.json"), []byte("combo"), 0644)
+
+		errCallback := errors.New("callback failed")
+		err := CopyDir(filepath.Join(dir, "Sol"), filepath.Join(dir, "Sol 1"), func(src, dst string) error {
+			return errCallback
+		})
+		if !errors.Is(err, errCallback) {
+			t.Errorf("got %v, want %v", err, errCallback)
+		}
+
+		if Exists(filepath.Join(dir, "Sol 1")) {
+			t.Error("the partial copy was not removed")
+		}
+	})
+}
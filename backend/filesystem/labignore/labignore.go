@@ -0,0 +1,215 @@
+// This package reads the .labignore file at the root of a lab. It uses the gitignore syntax and
+// tells which files and directories the app should act as if they didn't exist: they are hidden
+// from the tree and the directory list and the watcher doesn't report their changes.
+//
+// Hidden elements (starting with a dot) are ignored by default, a "!.name" line shows them again.
+// The .labmonster directory is always ignored.
+package labignore
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FileName = ".labignore"
+	// Directory of the app's own files, it can't be shown again
+	appDirName = ".labmonster"
+)
+
+// Rules applied before the ones of the .labignore file
+var defaultRules = []string{".*"}
+
+type rule struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+type Matcher struct {
+	root  string
+	rules []rule
+}
+
+// Parses gitignore style lines. Patterns are relative to root, an absolute path
+func New(root string, lines []string) *Matcher {
+	m := &Matcher{root: filepath.Clean(root)}
+	for _, l := range append(append([]string{}, defaultRules...), lines...) {
+		if r, ok := parseRule(l); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+
+	return m
+}
+
+func parseRule(line string) (rule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// "\#" and "\!" match names starting with those characters
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return rule{}, false
+	}
+
+	// A pattern without a slash matches at any depth, otherwise it starts at the root
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	r.segments = strings.Split(line, "/")
+	if !anchored {
+		r.segments = append([]string{"**"}, r.segments...)
+	}
+
+	return r, true
+}
+
+// Reports whether the element at absPath must be ignored. An element is ignored
+// if one of its parent directories is. The root itself is never ignored
+func (m *Matcher) Ignores(absPath string, isDir bool) bool {
+	rel, err := filepath.Rel(m.root, absPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	if segments[0] == appDirName {
+		return true
+	}
+
+	for i := 1; i <= len(segments); i++ {
+		dir := isDir || i < len(segments)
+		if m.matches(segments[:i], dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The last rule matching the path decides whether it's ignored
+func (m *Matcher) matches(segments []string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+
+		if matchSegments(r.segments, segments) {
+			ignored = !r.negate
+		}
+	}
+
+	return ignored
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		// A trailing "**" matches the content of a directory, not the directory itself
+		if len(pattern) == 1 {
+			return len(segments) > 0
+		}
+
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	ok, err := path.Match(pattern[0], segments[0])
+	if err != nil || !ok {
+		return false
+	}
+
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+type cachedMatcher struct {
+	modTime time.Time
+	matcher *Matcher
+}
+
+var (
+	mu    sync.Mutex
+	cache = make(map[string]cachedMatcher)
+)
+
+// Returns the matcher of the lab. The .labignore file is only parsed again when it
+// changed since the last call. A lab without .labignore only ignores the default rules
+func ForLab(labPath string) (*Matcher, error) {
+	p := filepath.Join(labPath, FileName)
+
+	var modTime time.Time
+	info, err := os.Stat(p)
+	if err == nil {
+		modTime = info.ModTime()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if c, ok := cache[labPath]; ok && c.modTime.Equal(modTime) {
+		return c.matcher, nil
+	}
+
+	lines, err := readLines(p)
+	if err != nil {
+		return nil, err
+	}
+
+	m := New(labPath, lines)
+	cache[labPath] = cachedMatcher{modTime, m}
+	return m, nil
+}
+
+func readLines(p string) ([]string, error) {
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := make([]string, 0)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+
+	return lines, s.Err()
+}
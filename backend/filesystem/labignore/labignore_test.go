@@ -0,0 +1,91 @@
+package labignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIgnores(t *testing.T) {
+	root := filepath.Join(os.TempDir(), "lab")
+	m := New(root, []string{
+		"# Enregistrements bruts",
+		"*.tmp",
+		"/exports/",
+		"Sol/**/draft.json",
+		"cache/",
+		"!.github",
+		"logs/**",
+		"!logs/keep.txt",
+	})
+
+	cases := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"notes.json", false, false},
+		{".labmonster", true, true},
+		{".labmonster/recentlyOpened.txt", false, true},
+		{".git", true, true},
+		{".github", true, false},
+		{"Sol/.hidden.json", false, true},
+		{"capture.tmp", false, true},
+		{"Sol/deep/capture.tmp", false, true},
+		{"exports", true, true},
+		{"exports", false, false},
+		{"exports/a.webm", false, true},
+		{"Sol/exports", true, false},
+		{"Sol/draft.json", false, true},
+		{"Sol/Combos/Corner/draft.json", false, true},
+		{"Ky/draft.json", false, false},
+		{"Ky/cache/frame.png", false, true},
+		{"logs", true, false},
+		{"logs/today.txt", false, true},
+		{"logs/keep.txt", false, false},
+		{"", true, false},
+	}
+
+	for _, c := range cases {
+		got := m.Ignores(filepath.Join(root, c.path), c.isDir)
+		if got != c.ignored {
+			t.Errorf("%q (dir: %v): got %v, want %v", c.path, c.isDir, got, c.ignored)
+		}
+	}
+}
+
+func TestForLab(t *testing.T) {
+	dir, err := os.MkdirTemp("", "testLabignore")
+	if err != nil {
+		t.Fatalf("an error occured while creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := ForLab(dir)
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if m.Ignores(filepath.Join(dir, "raw.webm"), false) {
+		t.Errorf("nothing but hidden files should be ignored without .labignore")
+	}
+
+	p := filepath.Join(dir, FileName)
+	err = os.WriteFile(p, []byte("*.webm\n"), 0644)
+	if err != nil {
+		t.Fatalf("couldn't write .labignore: %v", err)
+	}
+	// The cache relies on the modification time of the file
+	future := time.Now().Add(time.Second)
+	os.Chtimes(p, future, future)
+
+	m, err = ForLab(dir)
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if !m.Ignores(filepath.Join(dir, "raw.webm"), false) {
+		t.Errorf("the new .labignore was not read")
+	}
+}
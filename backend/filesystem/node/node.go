@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"io/fs"
 	"path/filepath"
-	"slices"
 	"strings"
 	"time"
 )
@@ -30,20 +29,22 @@ const (
 	SHEET       FileType = "SHEET"
 	VIDEO       FileType = "VIDEO"
 	IMAGE       FileType = "IMAGE"
+	CLIP        FileType = "CLIP"
+	PLAYLIST    FileType = "PLAYLIST"
+	ANNOTATIONS FileType = "ANNOTATIONS"
+	MARKERS     FileType = "MARKERS"
+	TAGS        FileType = "TAGS"
 	UNSUPPORTED FileType = "UNSUPPORTED"
 )
 
-var FTypes = []struct {
+type fileTypeEnumEntry = struct {
 	Value  FileType
 	TSName string
-}{
-	{GRAPH, "GRAPH"},
-	{SHEET, "SHEET"},
-	{VIDEO, "VIDEO"},
-	{IMAGE, "IMAGE"},
-	{UNSUPPORTED, "UNSUPPORTED"},
 }
 
+// Built from the file types known by the format registry
+var FTypes = fileTypesEnum(formats.fileTypes)
+
 // A node is the in-memory representation of a file or a directory on the user's machine
 type Node struct {
 	Name      string    `json:"name"`
@@ -51,6 +52,8 @@ type Node struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 	Extension string    `json:"extension"`
 	FileType  FileType  `json:"fileType"`
+	// Size in bytes, always 0 for directories
+	Size int64 `json:"size"`
 }
 
 type Nodes []*Node
@@ -77,14 +80,14 @@ func NewNode(name, extension string, nodeType DataType) Node {
 	}
 }
 
-// Takes an array of fs.DirEntry to create an array of type *Node and returns it.
-// This function ignore any element that starts with a dot (.git or .labmonster for example) as
-// it contains config files that are not relevant to the user
-func CreateNodesFromDirEntries(entries []fs.DirEntry) (Nodes, error) {
+// Takes an array of fs.DirEntry located in dirPath to create an array of type *Node and returns it.
+// Files without a known extension are recognized by their content.
+// Entries ignored by the lab's .labignore must be filtered out by the caller
+func CreateNodesFromDirEntries(dirPath string, entries []fs.DirEntry) (Nodes, error) {
 	dirNames := make(Nodes, 0)
 	for _, entry := range entries {
-		ext := filepath.Ext(entry.Name())
-		if entry.Name() == ext {
+		// Sidecar files are shown through the file they belong to
+		if !entry.IsDir() && IsSidecar(entry.Name()) {
 			continue
 		}
 
@@ -102,38 +105,24 @@ func CreateNodesFromDirEntries(entries []fs.DirEntry) (Nodes, error) {
 		if entry.IsDir() {
 			newNode.Type = DIR
 		} else {
-			newNode.FileType = DetectFileType(ext)
+			newNode.FileType = DetectFileTypeFromPath(filepath.Join(dirPath, entry.Name()))
 			newNode.Type = FILE
+			newNode.Size = info.Size()
 		}
 
 		dirNames = append(dirNames, &newNode)
 	}
 
-	slices.SortStableFunc(dirNames, func(iNode, jNode *Node) int {
-		if iNode.Type == jNode.Type {
-			return strings.Compare(strings.ToLower(iNode.Name), strings.ToLower(jNode.Name))
-		}
-
-		if iNode.Type == DIR {
-			return -1
-		}
-
-		return 1
-	})
+	SortNodes(dirNames, BY_NAME, false)
 
 	return dirNames, nil
 }
 
-// Given an extension, it wil return the corresponding FileType
-func DetectFileType(extension string) FileType {
-	switch extension {
-	case ".png", ".jpeg", ".gif", ".webp":
-		return IMAGE
-	case ".json":
-		return GRAPH
-	case ".mp4", ".mpeg", ".webm":
-		return VIDEO
-	default:
-		return UNSUPPORTED
-	}
+// A node placed in the lab's tree
+type TreeNode struct {
+	Node
+	// Path starting from the lab root, using forward slashes
+	Path string `json:"path"`
+	// Nil for files and for directories that weren't read because of the depth limit
+	Children []*TreeNode `json:"children"`
 }
@@ -0,0 +1,345 @@
+package node
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Number of bytes read at the start of a file to look for magic numbers
+const sniffLen = 512
+
+// A magic number is a sequence of bytes found at a given offset at the start of a file
+type MagicNumber struct {
+	Offset int
+	Bytes  []byte
+}
+
+// A signature matches a file header when every magic number it holds is found in it
+type Signature []MagicNumber
+
+func (s Signature) matches(header []byte) bool {
+	for _, m := range s {
+		end := m.Offset + len(m.Bytes)
+		if end > len(header) || !bytes.Equal(header[m.Offset:end], m.Bytes) {
+			return false
+		}
+	}
+
+	return len(s) > 0
+}
+
+// A format describes a kind of file the lab knows how to handle. The first extension
+// and the first MIME type are the canonical ones, used when the application writes a file
+// of this format
+type Format struct {
+	FileType   FileType
+	Extensions []string
+	MimeTypes  []string
+	Signatures []Signature
+	// A sidecar file holds data about another file and is named after it, followed
+	// by the sidecar's extension (setup.png.annotations for example).
+	// Sidecars are not listed in the file tree
+	Sidecar bool
+}
+
+// Canonical extension of the format, starting with a dot
+func (f Format) Extension() string {
+	if len(f.Extensions) == 0 {
+		return ""
+	}
+
+	return f.Extensions[0]
+}
+
+// Canonical MIME type of the format
+func (f Format) MimeType() string {
+	if len(f.MimeTypes) == 0 {
+		return ""
+	}
+
+	return f.MimeTypes[0]
+}
+
+type registry struct {
+	mu sync.RWMutex
+	// mu protects the following fields
+	fileTypes []FileType
+	formats   []Format
+}
+
+// Formats are tested in registration order when sniffing a file header, so formats
+// with more specific signatures must be registered first (MOV before MP4 for example)
+var formats = &registry{
+	fileTypes: []FileType{GRAPH, SHEET, VIDEO, IMAGE, CLIP, PLAYLIST, ANNOTATIONS, MARKERS, TAGS, UNSUPPORTED},
+	formats: []Format{
+		{
+			FileType:   GRAPH,
+			Extensions: []string{".json"},
+			MimeTypes:  []string{"application/json"},
+		},
+		{
+			FileType:   IMAGE,
+			Extensions: []string{".png"},
+			MimeTypes:  []string{"image/png"},
+			Signatures: []Signature{{{0, []byte("\x89PNG\r\n\x1a\n")}}},
+		},
+		{
+			FileType:   IMAGE,
+			Extensions: []string{".jpeg", ".jpg"},
+			MimeTypes:  []string{"image/jpeg"},
+			Signatures: []Signature{{{0, []byte{0xFF, 0xD8, 0xFF}}}},
+		},
+		{
+			FileType:   IMAGE,
+			Extensions: []string{".gif"},
+			MimeTypes:  []string{"image/gif"},
+			Signatures: []Signature{{{0, []byte("GIF87a")}}, {{0, []byte("GIF89a")}}},
+		},
+		{
+			FileType:   IMAGE,
+			Extensions: []string{".webp"},
+			MimeTypes:  []string{"image/webp"},
+			Signatures: []Signature{{{0, []byte("RIFF")}, {8, []byte("WEBP")}}},
+		},
+		{
+			FileType:   IMAGE,
+			Extensions: []string{".bmp"},
+			MimeTypes:  []string{"image/bmp", "image/x-ms-bmp"},
+			Signatures: []Signature{{{0, []byte("BM")}}},
+		},
+		{
+			FileType:   VIDEO,
+			Extensions: []string{".mov"},
+			MimeTypes:  []string{"video/quicktime"},
+			Signatures: []Signature{{{4, []byte("ftypqt  ")}}},
+		},
+		{
+			// HEIC, AVIF and M4A files are ISO media files too, only the major
+			// brands of MP4 videos are recognized
+			FileType:   VIDEO,
+			Extensions: []string{".mp4", ".m4v"},
+			MimeTypes:  []string{"video/mp4"},
+			Signatures: ftypSignatures("isom", "iso2", "iso4", "iso5", "iso6", "mp41", "mp42", "avc1", "dash", "mmp4", "M4V "),
+		},
+		{
+			FileType:   VIDEO,
+			Extensions: []string{".mpeg", ".mpg"},
+			MimeTypes:  []string{"video/mpeg"},
+			Signatures: []Signature{{{0, []byte{0x00, 0x00, 0x01, 0xBA}}}, {{0, []byte{0x00, 0x00, 0x01, 0xB3}}}},
+		},
+		{
+			// WebM and Matroska share the same EBML header. Both are videos so
+			// sniffing a .mkv file as a WebM one doesn't change its file type
+			FileType:   VIDEO,
+			Extensions: []string{".webm"},
+			MimeTypes:  []string{"video/webm"},
+			Signatures: []Signature{{{0, []byte{0x1A, 0x45, 0xDF, 0xA3}}}},
+		},
+		{
+			FileType:   VIDEO,
+			Extensions: []string{".mkv"},
+			MimeTypes:  []string{"video/x-matroska"},
+			Signatures: []Signature{{{0, []byte{0x1A, 0x45, 0xDF, 0xA3}}}},
+		},
+		{
+			FileType:   CLIP,
+			Extensions: []string{".clip"},
+			MimeTypes:  []string{"application/vnd.labmonster.clip+json"},
+		},
+		{
+			FileType:   PLAYLIST,
+			Extensions: []string{".playlist"},
+			MimeTypes:  []string{"application/vnd.labmonster.playlist+json"},
+		},
+		{
+			FileType:   ANNOTATIONS,
+			Extensions: []string{".annotations"},
+			MimeTypes:  []string{"application/vnd.labmonster.annotations+json"},
+			Sidecar:    true,
+		},
+		{
+			FileType:   MARKERS,
+			Extensions: []string{".markers"},
+			MimeTypes:  []string{"application/vnd.labmonster.markers+json"},
+			Sidecar:    true,
+		},
+		{
+			FileType:   TAGS,
+			Extensions: []string{".tags"},
+			MimeTypes:  []string{"application/vnd.labmonster.tags+json"},
+			Sidecar:    true,
+		},
+	},
+}
+
+// Adds a new format to the registry. If the format's file type is unknown, it is
+// registered too and becomes part of the FTypes enum bound to the frontend.
+// This function is meant to be called from an init function, before the app starts
+func RegisterFormat(f Format) {
+	formats.mu.Lock()
+	defer formats.mu.Unlock()
+
+	f.Extensions = slices.Clone(f.Extensions)
+	for i, ext := range f.Extensions {
+		f.Extensions[i] = strings.ToLower(ext)
+	}
+
+	formats.formats = append(formats.formats, f)
+
+	if !slices.Contains(formats.fileTypes, f.FileType) {
+		// UNSUPPORTED stays the last file type
+		formats.fileTypes = slices.Insert(formats.fileTypes, len(formats.fileTypes)-1, f.FileType)
+		FTypes = fileTypesEnum(formats.fileTypes)
+	}
+}
+
+// Returns the format matching the given extension. The comparison is case insensitive
+func FormatFromExtension(extension string) (Format, bool) {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+
+	extension = strings.ToLower(extension)
+	for _, f := range formats.formats {
+		if slices.Contains(f.Extensions, extension) {
+			return f, true
+		}
+	}
+
+	return Format{}, false
+}
+
+// Returns the format matching the given MIME type. Parameters such as "; codecs=vp9"
+// are ignored
+func FormatFromMime(mimetype string) (Format, bool) {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+
+	mimetype, _, _ = strings.Cut(mimetype, ";")
+	mimetype = strings.ToLower(strings.TrimSpace(mimetype))
+	for _, f := range formats.formats {
+		if slices.Contains(f.MimeTypes, mimetype) {
+			return f, true
+		}
+	}
+
+	return Format{}, false
+}
+
+// Returns the canonical extension of the first format registered for the given file type,
+// empty if there's none
+func Extension(t FileType) string {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+
+	for _, f := range formats.formats {
+		if f.FileType == t {
+			return f.Extension()
+		}
+	}
+
+	return ""
+}
+
+// Looks for a known signature in the first bytes of a file and returns the matching format
+func FormatFromContent(header []byte) (Format, bool) {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+
+	for _, f := range formats.formats {
+		for _, s := range f.Signatures {
+			if s.matches(header) {
+				return f, true
+			}
+		}
+	}
+
+	return Format{}, false
+}
+
+// Returns the extensions of every sidecar format
+func SidecarExtensions() []string {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+
+	exts := make([]string, 0)
+	for _, f := range formats.formats {
+		if f.Sidecar {
+			exts = append(exts, f.Extensions...)
+		}
+	}
+
+	return exts
+}
+
+// Reports whether the file name or path given belongs to a sidecar format
+func IsSidecar(name string) bool {
+	f, ok := FormatFromExtension(filepath.Ext(name))
+	return ok && f.Sidecar
+}
+
+// Given an extension, it wil return the corresponding FileType
+func DetectFileType(extension string) FileType {
+	f, ok := FormatFromExtension(extension)
+	if !ok {
+		return UNSUPPORTED
+	}
+
+	return f.FileType
+}
+
+// Given the absolute path to a file, returns its format. The extension is used first and,
+// if it isn't known, the start of the file is read to look for a known signature.
+// Files that can't be read, deleted ones for example, are only detected by their extension
+func FormatFromPath(path string) (Format, bool) {
+	if f, ok := FormatFromExtension(filepath.Ext(path)); ok {
+		return f, true
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Format{}, false
+	}
+	defer f.Close()
+
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return Format{}, false
+	}
+
+	return FormatFromContent(header[:n])
+}
+
+// Same as FormatFromPath but only returns the FileType of the format
+func DetectFileTypeFromPath(path string) FileType {
+	f, ok := FormatFromPath(path)
+	if !ok {
+		return UNSUPPORTED
+	}
+
+	return f.FileType
+}
+
+// Returns the signatures of ISO media files with one of the given major brands
+func ftypSignatures(brands ...string) []Signature {
+	signatures := make([]Signature, 0, len(brands))
+	for _, b := range brands {
+		signatures = append(signatures, Signature{{4, []byte("ftyp" + b)}})
+	}
+
+	return signatures
+}
+
+func fileTypesEnum(fileTypes []FileType) []fileTypeEnumEntry {
+	enum := make([]fileTypeEnumEntry, 0, len(fileTypes))
+	for _, ft := range fileTypes {
+		enum = append(enum, fileTypeEnumEntry{ft, string(ft)})
+	}
+
+	return enum
+}
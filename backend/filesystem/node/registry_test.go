@@ -0,0 +1,140 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectFileType(t *testing.T) {
+	tests := []struct {
+		extension string
+		want      FileType
+	}{
+		{".json", GRAPH},
+		{".png", IMAGE},
+		{".jpg", IMAGE},
+		{".JPEG", IMAGE},
+		{".bmp", IMAGE},
+		{".mp4", VIDEO},
+		{".mkv", VIDEO},
+		{".mov", VIDEO},
+		{".txt", UNSUPPORTED},
+		{"", UNSUPPORTED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.extension, func(t *testing.T) {
+			got := DetectFileType(tt.extension)
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFromContent(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00"), ".png"},
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, ".jpeg"},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), ".webp"},
+		{"mov", []byte("\x00\x00\x00\x14ftypqt  \x00\x00"), ".mov"},
+		{"mp4", []byte("\x00\x00\x00\x18ftypisom\x00\x00"), ".mp4"},
+		{"m4v", []byte("\x00\x00\x00\x18ftypM4V \x00\x00"), ".mp4"},
+		{"riff without webp", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), ""},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00"), ""},
+		{"avif", []byte("\x00\x00\x00\x1cftypavif\x00\x00"), ""},
+		{"m4a", []byte("\x00\x00\x00\x1cftypM4A \x00\x00"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := FormatFromContent(tt.header)
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("didn't want a format, got %v", f)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatal("no format found")
+			}
+
+			if f.Extension() != tt.want {
+				t.Errorf("got %s, want %s", f.Extension(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFromMime(t *testing.T) {
+	f, ok := FormatFromMime("video/webm;codecs=vp9")
+	if !ok {
+		t.Fatal("no format found")
+	}
+
+	if f.Extension() != ".webm" {
+		t.Errorf("got %s, want %s", f.Extension(), ".webm")
+	}
+}
+
+func TestExtension(t *testing.T) {
+	for fileType, want := range map[FileType]string{CLIP: ".clip", PLAYLIST: ".playlist", IMAGE: ".png", UNSUPPORTED: ""} {
+		if got := Extension(fileType); got != want {
+			t.Errorf("%s: got %q, want %q", fileType, got, want)
+		}
+	}
+}
+
+func TestDetectFileTypeFromPath(t *testing.T) {
+	dir, err := os.MkdirTemp("", "testSniff")
+	if err != nil {
+		t.Fatalf("couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "capture")
+	err = os.WriteFile(p, []byte("GIF89a\x01\x00\x01\x00"), 0644)
+	if err != nil {
+		t.Fatalf("couldn't write file: %v", err)
+	}
+
+	got := DetectFileTypeFromPath(p)
+	if got != IMAGE {
+		t.Errorf("got %s, want %s", got, IMAGE)
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	const NOTE FileType = "NOTE"
+	RegisterFormat(Format{
+		FileType:   NOTE,
+		Extensions: []string{".MD"},
+		MimeTypes:  []string{"text/markdown"},
+	})
+
+	if got := DetectFileType(".md"); got != NOTE {
+		t.Errorf("got %s, want %s", got, NOTE)
+	}
+
+	last := FTypes[len(FTypes)-1]
+	if last.Value != UNSUPPORTED {
+		t.Errorf("UNSUPPORTED should stay the last file type, got %s", last.Value)
+	}
+
+	found := false
+	for _, ft := range FTypes {
+		if ft.Value == NOTE {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("the new file type wasn't added to the enum: %v", FTypes)
+	}
+}
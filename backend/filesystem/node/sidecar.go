@@ -0,0 +1,90 @@
+package node
+
+import (
+	"errors"
+	"flow-poc/backend/filesystem/fsutil"
+	"os"
+)
+
+// A file holding data about another file, named after it with the extension of a sidecar
+// format appended: the annotations of "setup.png" are in "setup.png.annotations"
+type Sidecar struct {
+	// Absolute path of the sidecar
+	Path string
+	// Extension of the sidecar format
+	Ext string
+}
+
+// Returns the extension of the sidecar format of the given file type, empty if the
+// file type isn't a sidecar format
+func SidecarExtension(t FileType) string {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+
+	for _, f := range formats.formats {
+		if f.FileType == t && f.Sidecar && len(f.Extensions) != 0 {
+			return f.Extensions[0]
+		}
+	}
+
+	return ""
+}
+
+// Returns the sidecars of the file at absPath that exist on the user's machine
+func ExistingSidecars(absPath string) []Sidecar {
+	sidecars := make([]Sidecar, 0)
+	for _, ext := range SidecarExtensions() {
+		if fsutil.Exists(absPath + ext) {
+			sidecars = append(sidecars, Sidecar{absPath + ext, ext})
+		}
+	}
+
+	return sidecars
+}
+
+// Moves the sidecars of the file that was at oldPath next to the file now at newPath.
+// Every sidecar is moved even if one fails, errors are returned at the end
+func MoveSidecars(oldPath, newPath string) error {
+	errs := make([]error, 0)
+	for _, s := range ExistingSidecars(oldPath) {
+		errs = append(errs, moveSidecar(s.Path, newPath+s.Ext))
+	}
+
+	return errors.Join(errs...)
+}
+
+func moveSidecar(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !fsutil.IsCrossDevice(err) {
+		return err
+	}
+
+	// A sidecar left by a file that was removed outside of the app
+	os.Remove(dst)
+	if err := fsutil.CopyFile(src, dst); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+// Copies the sidecars of the file at src next to its copy at dst
+func CopySidecars(src, dst string) error {
+	errs := make([]error, 0)
+	for _, s := range ExistingSidecars(src) {
+		os.Remove(dst + s.Ext)
+		errs = append(errs, fsutil.CopyFile(s.Path, dst+s.Ext))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Removes the sidecars of the file at absPath
+func RemoveSidecars(absPath string) error {
+	errs := make([]error, 0)
+	for _, s := range ExistingSidecars(absPath) {
+		errs = append(errs, os.Remove(s.Path))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,63 @@
+package node
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+type SortMode string
+
+const (
+	BY_NAME     SortMode = "NAME"
+	BY_MODIFIED SortMode = "MODIFIED"
+	BY_TYPE     SortMode = "TYPE"
+	BY_SIZE     SortMode = "SIZE"
+)
+
+var SortModes = []struct {
+	Value  SortMode
+	TSName string
+}{
+	{BY_NAME, "NAME"},
+	{BY_MODIFIED, "MODIFIED"},
+	{BY_TYPE, "TYPE"},
+	{BY_SIZE, "SIZE"},
+}
+
+// Sorts nodes in place. Directories always come first, whatever the order. Nodes that are
+// equal for the given mode are sorted by name. An unknown mode sorts by name
+func SortNodes(nodes Nodes, mode SortMode, descending bool) {
+	slices.SortStableFunc(nodes, func(iNode, jNode *Node) int {
+		if iNode.Type != jNode.Type {
+			if iNode.Type == DIR {
+				return -1
+			}
+
+			return 1
+		}
+
+		c := 0
+		switch mode {
+		case BY_MODIFIED:
+			c = iNode.UpdatedAt.Compare(jNode.UpdatedAt)
+		case BY_TYPE:
+			c = cmp.Compare(iNode.FileType, jNode.FileType)
+			if c == 0 {
+				c = strings.Compare(strings.ToLower(iNode.Extension), strings.ToLower(jNode.Extension))
+			}
+		case BY_SIZE:
+			c = cmp.Compare(iNode.Size, jNode.Size)
+		}
+
+		if c == 0 {
+			c = strings.Compare(strings.ToLower(iNode.Name), strings.ToLower(jNode.Name))
+		}
+
+		if descending {
+			return -c
+		}
+
+		return c
+	})
+}
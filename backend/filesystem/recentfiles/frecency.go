@@ -0,0 +1,78 @@
+package recentfiles
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+type TimeWindow string
+
+const (
+	ALL_TIME   TimeWindow = "ALL_TIME"
+	TODAY      TimeWindow = "TODAY"
+	THIS_WEEK  TimeWindow = "THIS_WEEK"
+	THIS_MONTH TimeWindow = "THIS_MONTH"
+)
+
+var TimeWindows = []struct {
+	Value  TimeWindow
+	TSName string
+}{
+	{ALL_TIME, "ALL_TIME"},
+	{TODAY, "TODAY"},
+	{THIS_WEEK, "THIS_WEEK"},
+	{THIS_MONTH, "THIS_MONTH"},
+}
+
+// Returns the moment the window starts at. Weeks start on monday. An unknown window
+// covers all time
+func (w TimeWindow) Since(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	switch w {
+	case TODAY:
+		return day
+	case THIS_WEEK:
+		return day.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	case THIS_MONTH:
+		return day.AddDate(0, 0, 1-t.Day())
+	default:
+		return time.Time{}
+	}
+}
+
+// The weight of an opening decreases with its age. A file opened often a while ago can
+// still rank above a file opened once today
+var frecencyBuckets = []struct {
+	age    time.Duration
+	weight int
+}{
+	{4 * 24 * time.Hour, 100},
+	{14 * 24 * time.Hour, 70},
+	{31 * 24 * time.Hour, 50},
+	{90 * 24 * time.Hour, 30},
+}
+
+func frecency(f RecentFile, t time.Time) int {
+	age := t.Sub(f.LastOpened)
+	for _, b := range frecencyBuckets {
+		if age < b.age {
+			return f.OpenCount * b.weight
+		}
+	}
+
+	return f.OpenCount * 10
+}
+
+// Sorts files by frecency, the highest first. Files with the same score are sorted from
+// the most recently opened
+func rank(files []RecentFile, t time.Time) {
+	slices.SortStableFunc(files, func(a, b RecentFile) int {
+		if c := cmp.Compare(frecency(b, t), frecency(a, t)); c != 0 {
+			return c
+		}
+
+		return b.LastOpened.Compare(a.LastOpened)
+	})
+}
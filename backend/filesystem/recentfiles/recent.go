@@ -2,103 +2,264 @@ package recentfiles
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"flow-poc/backend/config"
-	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
-	recentlyOpenedFilename = "recentlyOpened.txt"
+	recentlyOpenedFilename = "recentlyOpened.json"
+	// Plain list of paths used before open times and counts were recorded
+	legacyRecentlyOpenedFilename = "recentlyOpened.txt"
 )
 
+// Replaced in tests to control the age of recent files
+var now = time.Now
+
+// A file opened recently along with how often it's opened
+type RecentFile struct {
+	// Path to the file starting from the lab root
+	Path       string    `json:"path"`
+	LastOpened time.Time `json:"lastOpened"`
+	OpenCount  int       `json:"openCount"`
+}
+
+type recentFilesFile struct {
+	Files []RecentFile `json:"files"`
+}
+
 // struct that handle recently opened files
 // The application will remember the last n files opened
 // where n is equal to the maxFile key just above
 type RecentlyOpened struct {
-	Cfg       *config.AppConfig
+	Cfg *config.AppConfig
+	// Recent files are changed both by the bound methods and by the watcher's goroutine.
+	// mu protects FilePaths and stats
+	mu sync.Mutex
+	// Paths of the recent files, the most recently opened first
 	FilePaths []string
 	maxFiles  int
+	// Open time and count of each path of FilePaths
+	stats map[string]*RecentFile
+	// Whether the recent files of the current lab were read. An empty list can be the
+	// result of removals that aren't saved yet
+	loaded bool
 }
 
 func NewRecentlyOpened(c *config.AppConfig, max int) *RecentlyOpened {
-	return &RecentlyOpened{c, make([]string, 0), max}
+	r := &RecentlyOpened{
+		Cfg:       c,
+		FilePaths: make([]string, 0),
+		maxFiles:  max,
+		stats:     make(map[string]*RecentFile),
+	}
+
+	c.Subscribe(func(change config.Change) error {
+		if !change.LabSwitched() {
+			return nil
+		}
+		return r.Reload(change.PreviousLab)
+	})
+
+	return r
 }
 
 func (r *RecentlyOpened) getLabPath() string {
-	return r.Cfg.ConfigFile.LabPath
+	return r.Cfg.LabPath()
 }
 
+// Returns the paths of the recent files, ranked by frecency
 func (r *RecentlyOpened) GetRecentlyOpenedFiles() ([]string, error) {
-	if len(r.FilePaths) == 0 {
-		err := r.LoadRecentlyOpended()
-		if err != nil {
-			return nil, err
+	files, err := r.GetRecentFiles(ALL_TIME)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+
+	return paths, nil
+}
+
+// Returns the recent files opened during the given time window, ranked by frecency
+func (r *RecentlyOpened) GetRecentFiles(window TimeWindow) ([]RecentFile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.ensureLoaded()
+	if err != nil {
+		return nil, err
+	}
+
+	t := now()
+	since := window.Since(t)
+	files := make([]RecentFile, 0, len(r.FilePaths))
+	for _, f := range r.recentFiles() {
+		if !f.LastOpened.Before(since) {
+			files = append(files, f)
 		}
 	}
-	return r.FilePaths, nil
+
+	rank(files, t)
+	return files, nil
 }
 
-// Truncate recently opended text file and save the paths contained
-// in the FilePaths array
+// Saves the recent files in the .labmonster directory, the most recently opened first
 func (r *RecentlyOpened) SaveRecentlyOpended() error {
-	p := r.getLabmonsterDirPath()
-	f, err := os.Create(p)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.save()
+}
+
+func (r *RecentlyOpened) save() error {
+	return r.saveTo(r.getLabPath())
+}
+
+func (r *RecentlyOpened) saveTo(labPath string) error {
+	b, err := json.MarshalIndent(recentFilesFile{r.recentFiles()}, "", "\t")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	for _, p := range r.FilePaths {
-		_, saveErr := fmt.Fprintln(f, p)
-		if saveErr != nil {
+	return os.WriteFile(filepath.Join(labPath, ".labmonster", recentlyOpenedFilename), b, 0644)
+}
+
+// Saves the recent files in the lab that was opened before and loads the ones of the
+// current lab. Used after switching labs
+func (r *RecentlyOpened) Reload(previousLabPath string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if previousLabPath != "" && r.loaded {
+		err := r.saveTo(previousLabPath)
+		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	r.setRecentFiles(nil)
+	r.loaded = false
+	return r.load()
 }
 
 // Prepend a path relative to the lab's root to the FilePath array. A path will not be present
-// twice in this array. RecentlyOpened.maxFiles sets the maximum of recently opened files
-// and this function will make sure the capacity is never exceeded.
+// twice in this array: opening it again increases its open count. RecentlyOpened.maxFiles sets
+// the maximum of recently opened files and this function will make sure the capacity is never
+// exceeded by forgetting the file with the lowest frecency.
 func (r *RecentlyOpened) AddRecentFile(pathFromLabRoot string) {
-	if len(r.FilePaths) == r.maxFiles {
-		r.FilePaths = r.FilePaths[0 : len(r.FilePaths)-1]
-	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Otherwise the saved recent files would be overwritten by this one on shutdown
+	r.ensureLoaded()
 
+	count := 0
 	if slices.Contains(r.FilePaths, pathFromLabRoot) {
-		r.RemoveRecent(pathFromLabRoot)
+		count = r.stat(pathFromLabRoot).OpenCount
+		r.removeRecent(pathFromLabRoot)
+	}
+
+	t := now()
+	if len(r.FilePaths) > 0 && len(r.FilePaths) >= r.maxFiles {
+		files := r.recentFiles()
+		rank(files, t)
+		r.removeRecent(files[len(files)-1].Path)
 	}
 
 	// Cannot make use of r.FilePaths's capacity since this line resets it
 	r.FilePaths = append([]string{pathFromLabRoot}, r.FilePaths...)
+	r.stats[pathFromLabRoot] = &RecentFile{pathFromLabRoot, t, count + 1}
 }
 
 // Replace a recent file with a new one. This method is used when renaming a file to make sure
 // the file can still be opened via the recent file command
 func (r *RecentlyOpened) ReplaceRecent(oldPath, newPath string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Otherwise the saved recent files would be read again over this change
+	r.ensureLoaded()
+
 	i := slices.Index(r.FilePaths, oldPath)
 	if i == -1 {
 		return
 	}
 
-	r.FilePaths = slices.Replace(r.FilePaths, i, i, newPath)
+	r.FilePaths = slices.Replace(r.FilePaths, i, i+1, newPath)
+	r.moveStat(oldPath, newPath)
 }
 
 // Remove a recent file. Used when deleting a file
 func (r *RecentlyOpened) RemoveRecent(pathFromLabRoot string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Otherwise the saved recent files would be read again over this change
+	r.ensureLoaded()
+
+	r.removeRecent(pathFromLabRoot)
+}
+
+func (r *RecentlyOpened) removeRecent(pathFromLabRoot string) {
 	r.FilePaths = slices.DeleteFunc(r.FilePaths, func(p string) bool {
 		return p == pathFromLabRoot
 	})
+	delete(r.stats, pathFromLabRoot)
 }
 
-// Read the recentFile.txt and load each line inside the RecentlyOpened.FilePaths array
+// Reads the saved recent files and replaces the ones in memory. Recent files saved by older
+// versions as a plain list of paths are migrated: their order is kept and they count as opened
+// once, when the list was last written
 func (r *RecentlyOpened) LoadRecentlyOpended() error {
-	p := r.getLabmonsterDirPath()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.load()
+}
+
+// Recent files are only read from the lab when needed
+func (r *RecentlyOpened) ensureLoaded() error {
+	if r.loaded {
+		return nil
+	}
+
+	return r.load()
+}
+
+func (r *RecentlyOpened) load() error {
+	b, err := os.ReadFile(r.getLabmonsterDirPath())
+	if errors.Is(err, os.ErrNotExist) {
+		err = r.migrateLegacyFile()
+		r.loaded = err == nil
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var rf recentFilesFile
+	if err := json.Unmarshal(b, &rf); err != nil {
+		return err
+	}
+
+	r.setRecentFiles(rf.Files)
+	r.loaded = true
+	return nil
+}
+
+func (r *RecentlyOpened) migrateLegacyFile() error {
+	p := filepath.Join(r.getLabPath(), ".labmonster", legacyRecentlyOpenedFilename)
 	f, err := os.Open(p)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -109,48 +270,153 @@ func (r *RecentlyOpened) LoadRecentlyOpended() error {
 	}
 	defer f.Close()
 
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	files := make([]RecentFile, 0)
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
-		r.FilePaths = append(r.FilePaths, scanner.Text())
+		if scanner.Text() == "" {
+			continue
+		}
+
+		// One second apart so the ranking keeps the order of the file
+		opened := info.ModTime().Add(-time.Duration(len(files)) * time.Second)
+		files = append(files, RecentFile{scanner.Text(), opened, 1})
 	}
 
 	if errScan := scanner.Err(); errScan != nil {
 		return errScan
 	}
 
-	return nil
+	r.setRecentFiles(files)
+	err = r.save()
+	if err != nil {
+		return err
+	}
+
+	f.Close()
+	return os.Remove(p)
 }
 
 // This function will try to open every recently opened file. If the file doesn't exists
 // anymore, it will remove it from the list. This function is called after deleting a directory
 // in order to check if any recent files were in that directory.
 func (r *RecentlyOpened) CheckIfRecentFileStillExists() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Otherwise the saved recent files would be read again over this change
+	r.ensureLoaded()
+
 	labPath := r.getLabPath()
 
-	for _, recentFile := range r.FilePaths {
+	// Iterates over a copy since removing a path shifts the following ones
+	for _, recentFile := range slices.Clone(r.FilePaths) {
 		path := filepath.Join(labPath, recentFile)
 
-		f, err := os.Open(path)
+		_, err := os.Stat(path)
 		if err != nil && os.IsNotExist(err) {
-			r.RemoveRecent(recentFile)
+			r.removeRecent(recentFile)
 		}
-
-		f.Close()
 	}
 }
 
-// Iterates over recently opened file list and replace every instance
-// of oldPathFromRoot with newPathFromRoot. This function is used after renaming a directory
-// to make sure every paths are still relevant with the user's machine.
+// Iterates over recently opened file list and replace oldPathFromRoot with newPathFromRoot.
+// Files located inside a renamed or moved directory follow it. This function is used after
+// renaming a directory to make sure every paths are still relevant with the user's machine.
 func (r *RecentlyOpened) ReconcilePaths(oldPathFromRoot, newPathFromRoot string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Otherwise the saved recent files would be read again over this change
+	r.ensureLoaded()
+
+	r.reconcilePaths(oldPathFromRoot, newPathFromRoot)
+}
+
+func (r *RecentlyOpened) reconcilePaths(oldPathFromRoot, newPathFromRoot string) {
+	oldPath := clean(oldPathFromRoot)
+	newPath := clean(newPathFromRoot)
+
 	for i, recentFile := range r.FilePaths {
-		if !strings.Contains(recentFile, oldPathFromRoot) {
+		p := clean(recentFile)
+
+		var line string
+		switch {
+		case p == oldPath:
+			line = newPath
+		case strings.HasPrefix(p, oldPath+"/"):
+			line = newPath + strings.TrimPrefix(p, oldPath)
+		default:
 			continue
 		}
 
-		line := strings.Replace(recentFile, oldPathFromRoot, newPathFromRoot, 1)
 		r.FilePaths[i] = line
+		r.moveStat(recentFile, line)
+	}
+}
+
+// Removes a recent file, or every recent file located inside a directory
+func (r *RecentlyOpened) removeUnder(pathFromLabRoot string) {
+	removed := clean(pathFromLabRoot)
+	for _, recentFile := range slices.Clone(r.FilePaths) {
+		p := clean(recentFile)
+		if p == removed || strings.HasPrefix(p, removed+"/") {
+			r.removeRecent(recentFile)
+		}
+	}
+}
+
+// Returns the recent files in the order of FilePaths. Paths without stats, like the ones
+// put directly into FilePaths, are returned as never opened
+func (r *RecentlyOpened) recentFiles() []RecentFile {
+	files := make([]RecentFile, 0, len(r.FilePaths))
+	for _, p := range r.FilePaths {
+		files = append(files, *r.stat(p))
+	}
+
+	return files
+}
+
+func (r *RecentlyOpened) setRecentFiles(files []RecentFile) {
+	r.FilePaths = make([]string, 0, len(files))
+	r.stats = make(map[string]*RecentFile, len(files))
+	for _, f := range files {
+		if _, ok := r.stats[f.Path]; ok {
+			continue
+		}
+
+		f := f
+		r.FilePaths = append(r.FilePaths, f.Path)
+		r.stats[f.Path] = &f
+	}
+}
+
+func (r *RecentlyOpened) stat(pathFromLabRoot string) *RecentFile {
+	if s, ok := r.stats[pathFromLabRoot]; ok {
+		return s
+	}
+
+	return &RecentFile{Path: pathFromLabRoot}
+}
+
+func (r *RecentlyOpened) moveStat(oldPath, newPath string) {
+	s, ok := r.stats[oldPath]
+	if !ok {
+		return
 	}
+
+	delete(r.stats, oldPath)
+	s.Path = newPath
+	r.stats[newPath] = s
+}
+
+// Paths are compared with forward slashes and without leading slash
+func clean(pathFromLabRoot string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(pathFromLabRoot)), "/")
 }
 
 func (r *RecentlyOpened) getLabmonsterDirPath() string {
@@ -1,12 +1,13 @@
 package recentfiles
 
 import (
-	"bufio"
+	"encoding/json"
 	"flow-poc/backend/config"
 	"os"
 	"path/filepath"
 	"slices"
 	"testing"
+	"time"
 )
 
 func initRecentlyOpened(t testing.TB, max int) (*RecentlyOpened, string) {
@@ -40,27 +41,24 @@ func assertRecentSaved(t testing.TB, tempDirPath string) {
 func assertContentMatchWithSaved(t testing.TB, tempDirPath string, r *RecentlyOpened) {
 	t.Helper()
 
-	f, err := os.Open(filepath.Join(tempDirPath, ".labmonster", recentlyOpenedFilename))
+	b, err := os.ReadFile(filepath.Join(tempDirPath, ".labmonster", recentlyOpenedFilename))
 	if err != nil {
 		t.Fatalf("couldn't open recently opended file: %v", err)
 	}
 
-	defer f.Close()
-
-	scanner := bufio.NewScanner(f)
-	cpt := 0
-	for scanner.Scan() {
-		got := scanner.Text()
-		want := r.FilePaths[cpt]
+	var saved recentFilesFile
+	if err = json.Unmarshal(b, &saved); err != nil {
+		t.Fatalf("couldn't read recently opended file: %v", err)
+	}
 
-		if got != want {
-			t.Fatalf("got %s, want %s", got, want)
-		}
-		cpt++
+	if len(saved.Files) != len(r.FilePaths) {
+		t.Fatalf("got %d files, want %d", len(saved.Files), len(r.FilePaths))
 	}
 
-	if err = scanner.Err(); err != nil {
-		t.Fatalf("couldn't read recently opended file: %v", err)
+	for i, f := range saved.Files {
+		if f.Path != r.FilePaths[i] {
+			t.Fatalf("got %s, want %s", f.Path, r.FilePaths[i])
+		}
 	}
 }
 
@@ -234,6 +232,209 @@ func TestCheckIfRecentFileStillExists(t *testing.T) {
 	}
 }
 
+func TestRemoveLastRecent(t *testing.T) {
+	ro, dir := initRecentlyOpened(t, 5)
+	defer os.RemoveAll(dir)
+
+	ro.AddRecentFile("Sol/oki.json")
+	if err := ro.SaveRecentlyOpended(); err != nil {
+		t.Fatalf("couldn't save recent files: %v", err)
+	}
+
+	// A new instance starts with the saved recent files
+	ro = NewRecentlyOpened(ro.Cfg, 5)
+	ro.RemoveRecent("Sol/oki.json")
+	ro.AddRecentFile("Ky/oki.json")
+
+	if slices.Compare(ro.FilePaths, []string{"Ky/oki.json"}) != 0 {
+		t.Errorf("the removed recent file came back: %v", ro.FilePaths)
+	}
+}
+
 func TestReconcilePaths(t *testing.T) {
-	// TODO: Implement
+	ro, dir := initRecentlyOpened(t, 5)
+	defer os.RemoveAll(dir)
+
+	ro.AddRecentFile("Solo.json")
+	ro.AddRecentFile("/Sol/oki.json")
+	ro.AddRecentFile("Sol/Combos/bnb.json")
+	ro.AddRecentFile("Sol")
+
+	ro.ReconcilePaths("Sol", "Sol Badguy")
+
+	want := []string{"Sol Badguy", "Sol Badguy/Combos/bnb.json", "Sol Badguy/oki.json", "Solo.json"}
+	if slices.Compare(ro.FilePaths, want) != 0 {
+		t.Errorf("got %v, want %v", ro.FilePaths, want)
+	}
+
+	files, _ := ro.GetRecentFiles(ALL_TIME)
+	for _, f := range files {
+		if f.OpenCount != 1 {
+			t.Errorf("the open count of %s was lost", f.Path)
+		}
+	}
+}
+
+// Makes the clock return the given times, one per call
+func setClock(t testing.TB, times ...time.Time) {
+	t.Helper()
+
+	i := 0
+	now = func() time.Time {
+		c := times[min(i, len(times)-1)]
+		i++
+		return c
+	}
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestFrecency(t *testing.T) {
+	monday := time.Date(2024, time.March, 4, 10, 0, 0, 0, time.Local)
+
+	t.Run("files opened often rank above files opened recently", func(t *testing.T) {
+		ro, dir := initRecentlyOpened(t, 3)
+		defer os.RemoveAll(dir)
+		setClock(t, monday.AddDate(0, 0, -10), monday.AddDate(0, 0, -9), monday.AddDate(0, 0, -8), monday, monday)
+
+		ro.AddRecentFile("oki.json")
+		ro.AddRecentFile("oki.json")
+		ro.AddRecentFile("oki.json")
+		ro.AddRecentFile("neutral.json")
+
+		files, err := ro.GetRecentFiles(ALL_TIME)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(files) != 2 || files[0].Path != "oki.json" || files[0].OpenCount != 3 {
+			t.Errorf("wrong ranking: %+v", files)
+		}
+	})
+
+	t.Run("the file with the lowest frecency is forgotten first", func(t *testing.T) {
+		ro, dir := initRecentlyOpened(t, 2)
+		defer os.RemoveAll(dir)
+		setClock(t, monday, monday, monday.Add(time.Hour), monday.Add(2*time.Hour))
+
+		ro.AddRecentFile("oki.json")
+		ro.AddRecentFile("oki.json")
+		ro.AddRecentFile("neutral.json")
+		ro.AddRecentFile("setup.webm")
+
+		want := []string{"setup.webm", "oki.json"}
+		if slices.Compare(ro.FilePaths, want) != 0 {
+			t.Errorf("got %v, want %v", ro.FilePaths, want)
+		}
+	})
+
+	t.Run("only files opened during the window are returned", func(t *testing.T) {
+		ro, dir := initRecentlyOpened(t, 3)
+		defer os.RemoveAll(dir)
+		setClock(t, monday.AddDate(0, 0, -1), monday, monday.Add(time.Hour))
+
+		ro.AddRecentFile("lastWeek.json")
+		ro.AddRecentFile("thisWeek.json")
+
+		files, err := ro.GetRecentFiles(THIS_WEEK)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(files) != 1 || files[0].Path != "thisWeek.json" {
+			t.Errorf("got %+v", files)
+		}
+	})
+}
+
+func TestMigrateLegacyRecentFiles(t *testing.T) {
+	ro, dir := initRecentlyOpened(t, 5)
+	defer os.RemoveAll(dir)
+
+	legacy := filepath.Join(dir, ".labmonster", legacyRecentlyOpenedFilename)
+	err := os.WriteFile(legacy, []byte("Sol/oki.json\nsetup.webm\n"), 0644)
+	if err != nil {
+		t.Fatalf("couldn't write the legacy file: %v", err)
+	}
+
+	paths, err := ro.GetRecentlyOpenedFiles()
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	want := []string{"Sol/oki.json", "setup.webm"}
+	if slices.Compare(paths, want) != 0 {
+		t.Errorf("got %v, want %v", paths, want)
+	}
+
+	assertRecentSaved(t, dir)
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("the legacy file should have been removed: %v", err)
+	}
+}
+
+func TestReloadAfterLabSwitch(t *testing.T) {
+	ro, sol := initRecentlyOpened(t, 5)
+	defer os.RemoveAll(sol)
+	other, ky := initRecentlyOpened(t, 5)
+	defer os.RemoveAll(ky)
+
+	other.AddRecentFile("Ky/oki.json")
+	if err := other.SaveRecentlyOpended(); err != nil {
+		t.Fatalf("couldn't save recent files: %v", err)
+	}
+
+	ro.AddRecentFile("Sol/oki.json")
+	ro.Cfg.ConfigFile.LabPath = ky
+	if err := ro.Reload(sol); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if slices.Compare(ro.FilePaths, []string{"Ky/oki.json"}) != 0 {
+		t.Errorf("the recent files of the new lab were not loaded: %v", ro.FilePaths)
+	}
+
+	ro.Cfg.ConfigFile.LabPath = sol
+	if err := ro.Reload(ky); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if slices.Compare(ro.FilePaths, []string{"Sol/oki.json"}) != 0 {
+		t.Errorf("the recent files of the previous lab were not saved: %v", ro.FilePaths)
+	}
+}
+
+func TestFollowsSwitchedLab(t *testing.T) {
+	_, sol := initRecentlyOpened(t, 5)
+	defer os.RemoveAll(sol)
+	other, ky := initRecentlyOpened(t, 5)
+	defer os.RemoveAll(ky)
+
+	other.AddRecentFile("Ky/oki.json")
+	if err := other.SaveRecentlyOpended(); err != nil {
+		t.Fatalf("couldn't save recent files: %v", err)
+	}
+
+	configDir := t.TempDir()
+	c := &config.AppConfig{Paths: config.Paths{ConfigDir: configDir, DataDir: configDir}}
+	ro := NewRecentlyOpened(c, 5)
+	for _, p := range []string{sol, ky} {
+		if _, err := c.AddLab(filepath.Base(p), p); err != nil {
+			t.Fatalf("couldn't add lab: %v", err)
+		}
+	}
+
+	if err := c.SwitchLab(sol); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+	ro.AddRecentFile("Sol/oki.json")
+
+	if err := c.SwitchLab(ky); err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if slices.Compare(ro.FilePaths, []string{"Ky/oki.json"}) != 0 {
+		t.Errorf("the recent files of the new lab were not loaded: %v", ro.FilePaths)
+	}
+
+	assertRecentSaved(t, sol)
 }
@@ -0,0 +1,44 @@
+package recentfiles
+
+import (
+	"flow-poc/backend/watcher"
+	"path/filepath"
+)
+
+// Keeps the recent files in sync with the files renamed, moved or deleted outside of the app
+func (r *RecentlyOpened) Watch(w *watcher.Watcher) {
+	w.Subscribe(r.HandleFsEvent)
+}
+
+// Reconciles the recent files with an event of the watcher. The paths of the event must be
+// absolute, as sent by the watcher
+func (r *RecentlyOpened) HandleFsEvent(e watcher.Event) {
+	if e.Op != watcher.Move && e.Op != watcher.Rename && e.Op != watcher.Remove {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.ensureLoaded(); err != nil {
+		return
+	}
+
+	labPath := r.getLabPath()
+	p, err := filepath.Rel(labPath, e.Path)
+	if err != nil {
+		return
+	}
+
+	if e.Op == watcher.Remove {
+		r.removeUnder(p)
+		return
+	}
+
+	old, err := filepath.Rel(labPath, e.OldPath)
+	if err != nil {
+		return
+	}
+
+	r.reconcilePaths(old, p)
+}
@@ -0,0 +1,65 @@
+package recentfiles
+
+import (
+	"flow-poc/backend/watcher"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestHandleFsEvent(t *testing.T) {
+	ro, dir := initRecentlyOpened(t, 5)
+	defer os.RemoveAll(dir)
+
+	ro.AddRecentFile("Sol/oki.json")
+	ro.AddRecentFile("Ky/oki.json")
+	ro.AddRecentFile("setup.webm")
+
+	ro.HandleFsEvent(watcher.Event{
+		Op:      watcher.Rename,
+		Path:    filepath.Join(dir, "Sol Badguy"),
+		OldPath: filepath.Join(dir, "Sol"),
+	})
+	ro.HandleFsEvent(watcher.Event{
+		Op:      watcher.Move,
+		Path:    filepath.Join(dir, "Médias", "setup.webm"),
+		OldPath: filepath.Join(dir, "setup.webm"),
+	})
+	ro.HandleFsEvent(watcher.Event{
+		Op:      watcher.Remove,
+		Path:    filepath.Join(dir, "Ky"),
+		OldPath: filepath.Join(dir, "Ky"),
+	})
+
+	want := []string{"Médias/setup.webm", "Sol Badguy/oki.json"}
+	if slices.Compare(ro.FilePaths, want) != 0 {
+		t.Errorf("got %v, want %v", ro.FilePaths, want)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	ro, dir := initRecentlyOpened(t, 5)
+	defer os.RemoveAll(dir)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			ro.AddRecentFile("Sol/oki.json")
+			ro.GetRecentlyOpenedFiles()
+		}()
+		go func() {
+			defer wg.Done()
+			ro.HandleFsEvent(watcher.Event{
+				Op:      watcher.Remove,
+				Path:    filepath.Join(dir, "Sol"),
+				OldPath: filepath.Join(dir, "Sol"),
+			})
+		}()
+	}
+
+	wg.Wait()
+}
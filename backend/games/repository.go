@@ -2,23 +2,28 @@ package games
 
 import (
 	"context"
+	"flow-poc/backend/db"
 	"flow-poc/backend/db/repository"
 )
 
 type GameRepository struct {
-	q *repository.Queries
+	conn *db.Conn
 }
 
-func NewGameRepository(q *repository.Queries) *GameRepository {
+func NewGameRepository(conn *db.Conn) *GameRepository {
 	return &GameRepository{
-		q,
+		conn,
 	}
 }
 
 func (gr *GameRepository) AddGame(newGame repository.AddGameParams) (repository.Game, error) {
 	ctx := context.Background()
+	q, err := gr.conn.Queries()
+	if err != nil {
+		return repository.Game{}, err
+	}
 
-	game, err := gr.q.AddGame(ctx, newGame)
+	game, err := q.AddGame(ctx, newGame)
 	if err != nil {
 		return repository.Game{}, err
 	}
@@ -28,8 +33,12 @@ func (gr *GameRepository) AddGame(newGame repository.AddGameParams) (repository.
 
 func (gr *GameRepository) GetOneGame(id int64) (repository.Game, error) {
 	ctx := context.Background()
+	q, err := gr.conn.Queries()
+	if err != nil {
+		return repository.Game{}, err
+	}
 
-	game, err := gr.q.GetOneGame(ctx, id)
+	game, err := q.GetOneGame(ctx, id)
 	if err != nil {
 		return repository.Game{}, err
 	}
@@ -39,8 +48,12 @@ func (gr *GameRepository) GetOneGame(id int64) (repository.Game, error) {
 
 func (gr *GameRepository) ListGames() ([]repository.Game, error) {
 	ctx := context.Background()
+	q, err := gr.conn.Queries()
+	if err != nil {
+		return []repository.Game{}, err
+	}
 
-	games, err := gr.q.ListGames(ctx)
+	games, err := q.ListGames(ctx)
 	if err != nil {
 		return []repository.Game{}, err
 	}
@@ -50,8 +63,12 @@ func (gr *GameRepository) ListGames() ([]repository.Game, error) {
 
 func (gr *GameRepository) UpdateGame(editedGame repository.EditGameParams) error {
 	ctx := context.Background()
+	q, err := gr.conn.Queries()
+	if err != nil {
+		return err
+	}
 
-	err := gr.q.EditGame(ctx, editedGame)
+	err = q.EditGame(ctx, editedGame)
 	if err != nil {
 		return err
 	}
@@ -61,8 +78,12 @@ func (gr *GameRepository) UpdateGame(editedGame repository.EditGameParams) error
 
 func (gr *GameRepository) DeleteGame(id int64) error {
 	ctx := context.Background()
+	q, err := gr.conn.Queries()
+	if err != nil {
+		return err
+	}
 
-	err := gr.q.DeleteGame(ctx, id)
+	err = q.DeleteGame(ctx, id)
 	if err != nil {
 		return err
 	}
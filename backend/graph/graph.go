@@ -54,10 +54,17 @@ type GraphEdge struct {
 	InteractionWidth int         `json:"interactionWidth"`
 }
 
+// Information about a graph that isn't drawn in it
+type GraphMetadata struct {
+	Tags []string `json:"tags"`
+}
+
 type Graph struct {
 	Nodes    []GraphNode   `json:"nodes"`
 	Edges    []GraphEdge   `json:"edges"`
 	Viewport GraphViewport `json:"viewport"`
+	// Nil for graphs that were never tagged
+	Metadata *GraphMetadata `json:"metadata,omitempty"`
 }
 
 // Returns a JSON marshaled graph. This graph is the starting point of all new files
@@ -0,0 +1,137 @@
+package graph
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+)
+
+// Subset of the JSON Canvas format used by Obsidian's .canvas files
+type canvasNode struct {
+	Id     string  `json:"id"`
+	Type   string  `json:"type"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Text   string  `json:"text"`
+	File   string  `json:"file"`
+	Url    string  `json:"url"`
+	Label  string  `json:"label"`
+}
+
+type canvasEdge struct {
+	Id       string `json:"id"`
+	FromNode string `json:"fromNode"`
+	FromSide string `json:"fromSide"`
+	ToNode   string `json:"toNode"`
+	ToSide   string `json:"toSide"`
+	Label    string `json:"label"`
+}
+
+type canvas struct {
+	Nodes []canvasNode `json:"nodes"`
+	Edges []canvasEdge `json:"edges"`
+}
+
+// Handles are named after their node's id followed by their position
+var canvasSides = map[string]string{
+	"top":    "top",
+	"right":  "right",
+	"bottom": "bot",
+	"left":   "left",
+}
+
+// Converts an Obsidian canvas into a graph. Files embedded in the canvas are
+// referenced relatively to the vault's root, so filesPrefix is prepended to them
+// to get a path starting from the lab root. The mediaType function is used to know if
+// a file should become an image or a video node; other files become text nodes
+func FromObsidianCanvas(b []byte, filesPrefix string, mediaType func(file string) string) (Graph, error) {
+	var c canvas
+	err := json.Unmarshal(b, &c)
+	if err != nil {
+		return Graph{}, err
+	}
+
+	g := Graph{
+		Nodes: make([]GraphNode, 0, len(c.Nodes)),
+		Edges: make([]GraphEdge, 0, len(c.Edges)),
+		Viewport: GraphViewport{
+			Zoom: 1,
+		},
+	}
+
+	for _, cn := range c.Nodes {
+		n := GraphNode{
+			Id:       cn.Id,
+			NodeType: "custom",
+			Position: GraphNodePosition{X: cn.X, Y: cn.Y},
+			Style:    GraphNodeStyle{Width: cn.Width, Height: cn.Height},
+		}
+
+		switch cn.Type {
+		case "text":
+			n.Data.Text = cn.Text
+		case "link":
+			n.Data.Text = cn.Url
+		case "group":
+			n.Data.Text = cn.Label
+		case "file":
+			file := path.Join(filesPrefix, cn.File)
+			if t := mediaType(file); t != "" {
+				n.NodeType = t
+				n.Data.Image = file
+			} else {
+				n.Data.Text = file
+			}
+		}
+
+		g.Nodes = append(g.Nodes, n)
+	}
+
+	for _, ce := range c.Edges {
+		g.Edges = append(g.Edges, GraphEdge{
+			Id:           ce.Id,
+			Label:        ce.Label,
+			Source:       ce.FromNode,
+			Target:       ce.ToNode,
+			SourceHandle: canvasHandle(ce.FromNode, ce.FromSide),
+			TargetHandle: canvasHandle(ce.ToNode, ce.ToSide),
+			MarkerEnd: EdgeMarker{
+				EdgeType: "arrowclosed",
+				Width:    20,
+				Height:   20,
+			},
+		})
+	}
+
+	return g, nil
+}
+
+// Converts a markdown note into a graph holding a single text node. The YAML front matter
+// Obsidian adds at the top of some notes is dropped
+func FromMarkdown(text string) Graph {
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		if _, body, found := strings.Cut(rest, "\n---"); found {
+			text = strings.TrimLeft(body, "-\r\n")
+		}
+	}
+
+	g := GetInitGraph()
+	g.Nodes[0].Data.Text = strings.TrimSpace(text)
+	g.Nodes[0].Style = GraphNodeStyle{
+		Width:  "400",
+		Height: "300",
+	}
+
+	return g
+}
+
+func canvasHandle(nodeId, side string) string {
+	s, ok := canvasSides[side]
+	if !ok {
+		return ""
+	}
+
+	return nodeId + s
+}
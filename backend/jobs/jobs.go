@@ -0,0 +1,238 @@
+// This package runs long file system operations in the background. Each operation is a job
+// that reports its progress to the frontend through Wails events and can be cancelled.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"flow-poc/backend/config"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// Emitted with a Job each time a running job makes progress
+	ProgressEvent = "jobprogress"
+	// Emitted with a Job once a job is over, whatever the outcome
+	EndEvent = "jobend"
+	// Minimum delay between two progress events of the same job
+	progressInterval = 100 * time.Millisecond
+)
+
+var ErrJobNotFound = errors.New("no running job with this id")
+
+type State string
+
+const (
+	RUNNING   State = "running"
+	DONE      State = "done"
+	FAILED    State = "failed"
+	CANCELLED State = "cancelled"
+)
+
+// Snapshot of a job sent to the frontend
+type Job struct {
+	Id string `json:"id"`
+	// Name of the operation, "move" or "duplicate" for instance
+	Op string `json:"op"`
+	// Element the operation works on, starting from the lab root
+	Path       string `json:"path"`
+	State      State  `json:"state"`
+	TotalBytes int64  `json:"totalBytes"`
+	DoneBytes  int64  `json:"doneBytes"`
+	TotalItems int    `json:"totalItems"`
+	DoneItems  int    `json:"doneItems"`
+	// File being processed
+	Current   string    `json:"current"`
+	StartedAt time.Time `json:"startedAt"`
+	// Set once the job is over. Error is empty if it succeeded
+	Error  string `json:"error"`
+	Result any    `json:"result"`
+}
+
+// Function run by a job. It must stop as soon as ctx is done
+type Func func(ctx context.Context, r *Reporter) (any, error)
+
+type Manager struct {
+	Cfg    *config.AppConfig
+	mu     sync.Mutex
+	jobs   map[string]*Reporter
+	nextId int
+	// Replaced in tests since there is no Wails runtime to send events to
+	emit func(name string, job Job)
+}
+
+func NewManager(cfg *config.AppConfig) *Manager {
+	m := &Manager{
+		Cfg:  cfg,
+		jobs: make(map[string]*Reporter),
+	}
+
+	m.emit = func(name string, job Job) {
+		if m.Cfg.Ctx != nil {
+			runtime.EventsEmit(m.Cfg.Ctx, name, job)
+		}
+	}
+
+	return m
+}
+
+// Runs fn in its own goroutine and returns the id of the job
+func (m *Manager) Start(op, pathFromLabRoot string, fn Func) string {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.mu.Lock()
+	m.nextId++
+	r := &Reporter{
+		job: Job{
+			Id:        fmt.Sprint(m.nextId),
+			Op:        op,
+			Path:      pathFromLabRoot,
+			State:     RUNNING,
+			StartedAt: time.Now(),
+		},
+		labPath: m.Cfg.LabPath(),
+		cancel:  cancel,
+		emit:    m.emit,
+	}
+	m.jobs[r.job.Id] = r
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		result, err := fn(ctx, r)
+
+		m.mu.Lock()
+		delete(m.jobs, r.job.Id)
+		m.mu.Unlock()
+
+		r.end(result, err, ctx.Err() != nil)
+	}()
+
+	return r.job.Id
+}
+
+// Returns every running job, oldest first
+func (m *Manager) ListJobs() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, r := range m.jobs {
+		jobs = append(jobs, r.snapshot())
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(jobs[j].StartedAt)
+	})
+
+	return jobs
+}
+
+// Asks a running job to stop. The job ends with the cancelled state once it
+// has undone what it started
+func (m *Manager) CancelJob(id string) error {
+	m.mu.Lock()
+	r, ok := m.jobs[id]
+	m.mu.Unlock()
+
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	r.cancel()
+	return nil
+}
+
+// Collects the progress of a job and sends it to the frontend. It implements fsutil.Progress
+type Reporter struct {
+	mu       sync.Mutex
+	job      Job
+	lastEmit time.Time
+	labPath  string
+	cancel   context.CancelFunc
+	emit     func(name string, job Job)
+}
+
+// Sets the amount of work the job has to do
+func (r *Reporter) SetTotal(bytes int64, items int) {
+	r.mu.Lock()
+	r.job.TotalBytes = bytes
+	r.job.TotalItems = items
+	r.mu.Unlock()
+
+	r.progress(true)
+}
+
+func (r *Reporter) AddBytes(n int64, path string) {
+	r.mu.Lock()
+	r.job.DoneBytes += n
+	r.job.Current = r.fromLabRoot(path)
+	r.mu.Unlock()
+
+	r.progress(false)
+}
+
+func (r *Reporter) ItemDone(path string) {
+	r.mu.Lock()
+	r.job.DoneItems++
+	r.job.Current = r.fromLabRoot(path)
+	r.mu.Unlock()
+
+	r.progress(false)
+}
+
+// Handlers report absolute paths but the frontend only knows paths starting from the lab root
+func (r *Reporter) fromLabRoot(path string) string {
+	rel, err := filepath.Rel(r.labPath, path)
+	if err != nil || !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+func (r *Reporter) snapshot() Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.job
+}
+
+// Sends the progress of the job unless the last event is too recent
+func (r *Reporter) progress(force bool) {
+	r.mu.Lock()
+	if !force && time.Since(r.lastEmit) < progressInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastEmit = time.Now()
+	job := r.job
+	r.mu.Unlock()
+
+	r.emit(ProgressEvent, job)
+}
+
+func (r *Reporter) end(result any, err error, cancelled bool) {
+	r.mu.Lock()
+	switch {
+	case err == nil:
+		r.job.State = DONE
+		r.job.Result = result
+	case cancelled:
+		r.job.State = CANCELLED
+		r.job.Error = err.Error()
+	default:
+		r.job.State = FAILED
+		r.job.Error = err.Error()
+	}
+	job := r.job
+	r.mu.Unlock()
+
+	r.emit(EndEvent, job)
+}
@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"flow-poc/backend/config"
+	"testing"
+	"time"
+)
+
+// Creates a manager whose end events are sent to the returned channel
+func createManager(t testing.TB) (*Manager, chan Job) {
+	t.Helper()
+
+	ended := make(chan Job, 1)
+	m := NewManager(&config.AppConfig{
+		ConfigFile: config.ConfigFile{
+			LabPath: "/lab",
+		},
+	})
+	m.emit = func(name string, job Job) {
+		if name == EndEvent {
+			ended <- job
+		}
+	}
+
+	return m, ended
+}
+
+func waitForEnd(t testing.TB, ended chan Job) Job {
+	t.Helper()
+
+	select {
+	case job := <-ended:
+		return job
+	case <-time.After(time.Second):
+		t.Fatal("the job never ended")
+		return Job{}
+	}
+}
+
+func TestJobs(t *testing.T) {
+	t.Run("a job reports its progress and result", func(t *testing.T) {
+		m, ended := createManager(t)
+
+		m.Start("duplicate", "Sol", func(ctx context.Context, r *Reporter) (any, error) {
+			r.SetTotal(10, 2)
+			r.AddBytes(4, "/lab/Sol/a.webm")
+			r.ItemDone("/lab/Sol/a.webm")
+			return "Sol 1", nil
+		})
+
+		job := waitForEnd(t, ended)
+		if job.State != DONE || job.Result != "Sol 1" {
+			t.Errorf("wrong end state: %+v", job)
+		}
+
+		if job.DoneBytes != 4 || job.DoneItems != 1 || job.Current != "Sol/a.webm" {
+			t.Errorf("wrong progress: %+v", job)
+		}
+
+		if len(m.ListJobs()) != 0 {
+			t.Errorf("an ended job is still listed")
+		}
+	})
+
+	t.Run("a running job can be cancelled", func(t *testing.T) {
+		m, ended := createManager(t)
+		started := make(chan struct{})
+
+		id := m.Start("move", "Sol", func(ctx context.Context, r *Reporter) (any, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		<-started
+
+		jobs := m.ListJobs()
+		if len(jobs) != 1 || jobs[0].Id != id || jobs[0].State != RUNNING {
+			t.Fatalf("the running job is not listed: %+v", jobs)
+		}
+
+		err := m.CancelJob(id)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		job := waitForEnd(t, ended)
+		if job.State != CANCELLED {
+			t.Errorf("got state %s, want %s", job.State, CANCELLED)
+		}
+	})
+
+	t.Run("a failing job reports its error", func(t *testing.T) {
+		m, ended := createManager(t)
+
+		m.Start("delete", "Sol", func(ctx context.Context, r *Reporter) (any, error) {
+			return nil, errors.New("disk full")
+		})
+
+		job := waitForEnd(t, ended)
+		if job.State != FAILED || job.Error != "disk full" {
+			t.Errorf("wrong end state: %+v", job)
+		}
+	})
+
+	t.Run("cancel a job that doesn't exist", func(t *testing.T) {
+		m, _ := createManager(t)
+
+		err := m.CancelJob("42")
+		if !errors.Is(err, ErrJobNotFound) {
+			t.Errorf("got %v, want %v", err, ErrJobNotFound)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package marker
+
+import "fmt"
+
+type MarkersFileError struct {
+	videoPath string
+	err       error
+}
+
+func (m *MarkersFileError) Error() string {
+	return fmt.Sprintf("couldn't access markers of %s: %v", m.videoPath, m.err)
+}
+
+func (m *MarkersFileError) Unwrap() error {
+	return m.err
+}
+
+type MarkerError struct {
+	id  string
+	err error
+}
+
+func (m *MarkerError) Error() string {
+	return fmt.Sprintf("marker %s: %v", m.id, m.err)
+}
+
+func (m *MarkerError) Unwrap() error {
+	return m.err
+}
+
+type SearchError struct {
+	err error
+}
+
+func (s *SearchError) Error() string {
+	return fmt.Sprintf("couldn't search markers: %v", s.err)
+}
+
+func (s *SearchError) Unwrap() error {
+	return s.err
+}
@@ -0,0 +1,352 @@
+// This package handles timestamped markers placed on the lab's recordings.
+// Markers of a video are stored in a sidecar file next to it.
+package marker
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/fsutil"
+	"flow-poc/backend/filesystem/labignore"
+	"flow-poc/backend/filesystem/node"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Extension appended to a video's name to get its markers file
+var sidecarExtension = node.SidecarExtension(node.MARKERS)
+
+var (
+	ErrNotAVideo       = errors.New("markers can only be added to videos")
+	ErrNegativeTime    = errors.New("a marker can't be placed before the start of the video")
+	ErrMarkerNotFound  = errors.New("marker not found")
+	ErrEmptyMarkerNote = errors.New("a marker needs a note")
+)
+
+// Link between a marker and a node of a graph
+type NodeLink struct {
+	// Path to the graph starting from the lab root
+	Graph  string `json:"graph"`
+	NodeId string `json:"nodeId"`
+}
+
+type Marker struct {
+	Id string `json:"id"`
+	// Position of the marker in the video, in seconds
+	Time  float64    `json:"time"`
+	Note  string     `json:"note"`
+	Links []NodeLink `json:"links"`
+}
+
+// A marker found while searching through every video of the lab
+type SearchResult struct {
+	// Path to the video starting from the lab root
+	Video  string `json:"video"`
+	Marker Marker `json:"marker"`
+}
+
+// Markers found by a search
+type Search struct {
+	Results []SearchResult `json:"results"`
+	// Paths to the videos, starting from the lab root, whose markers file couldn't be read.
+	// Their markers are missing from the results
+	Unreadable []string `json:"unreadable"`
+}
+
+type markersFile struct {
+	Markers []Marker `json:"markers"`
+}
+
+type MarkerHandler struct {
+	Cfg *config.AppConfig
+	// Serializes the changes to markers files so concurrent calls don't lose each other's markers
+	mu sync.Mutex
+}
+
+func NewMarkerHandler(cfg *config.AppConfig) *MarkerHandler {
+	return &MarkerHandler{
+		Cfg: cfg,
+	}
+}
+
+func (mh *MarkerHandler) GetLabPath() string {
+	return mh.Cfg.LabPath()
+}
+
+// Returns the markers of a video sorted by time. A video without markers returns an empty list
+func (mh *MarkerHandler) ListMarkers(videoPathFromLabRoot string) ([]Marker, error) {
+	p, err := mh.videoPath(videoPathFromLabRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return readMarkers(p)
+}
+
+// Adds a marker to a video and returns it with its generated id
+func (mh *MarkerHandler) AddMarker(videoPathFromLabRoot string, m Marker) (Marker, error) {
+	if err := m.validate(); err != nil {
+		return Marker{}, err
+	}
+
+	p, err := mh.videoPath(videoPathFromLabRoot)
+	if err != nil {
+		return Marker{}, err
+	}
+
+	mh.mu.Lock()
+	defer mh.mu.Unlock()
+
+	markers, err := readMarkers(p)
+	if err != nil {
+		return Marker{}, err
+	}
+
+	m.Id, err = newMarkerId()
+	if err != nil {
+		return Marker{}, err
+	}
+
+	if m.Links == nil {
+		m.Links = []NodeLink{}
+	}
+
+	markers = append(markers, m)
+	return m, writeMarkers(p, markers)
+}
+
+// Replaces the marker sharing the same id as the one given
+func (mh *MarkerHandler) EditMarker(videoPathFromLabRoot string, m Marker) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+
+	p, err := mh.videoPath(videoPathFromLabRoot)
+	if err != nil {
+		return err
+	}
+
+	mh.mu.Lock()
+	defer mh.mu.Unlock()
+
+	markers, err := readMarkers(p)
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(markers, func(marker Marker) bool {
+		return marker.Id == m.Id
+	})
+	if i == -1 {
+		return &MarkerError{m.Id, ErrMarkerNotFound}
+	}
+
+	if m.Links == nil {
+		m.Links = []NodeLink{}
+	}
+
+	markers[i] = m
+	return writeMarkers(p, markers)
+}
+
+// Removes a marker from a video. The sidecar file is deleted along with the last marker
+func (mh *MarkerHandler) RemoveMarker(videoPathFromLabRoot, id string) error {
+	p, err := mh.videoPath(videoPathFromLabRoot)
+	if err != nil {
+		return err
+	}
+
+	mh.mu.Lock()
+	defer mh.mu.Unlock()
+
+	markers, err := readMarkers(p)
+	if err != nil {
+		return err
+	}
+
+	n := len(markers)
+	markers = slices.DeleteFunc(markers, func(m Marker) bool {
+		return m.Id == id
+	})
+
+	if len(markers) == n {
+		return &MarkerError{id, ErrMarkerNotFound}
+	}
+
+	return writeMarkers(p, markers)
+}
+
+// Looks for markers of every video of the lab whose note contains the query or that are
+// linked to a graph whose path contains it. The search is case insensitive and an empty query
+// returns every marker. Results are sorted by video then by time. Markers left by a video that
+// doesn't exist anymore are skipped, as well as the ones of ignored elements
+func (mh *MarkerHandler) SearchMarkers(query string) (Search, error) {
+	labPath := mh.GetLabPath()
+	query = strings.ToLower(strings.TrimSpace(query))
+	search := Search{make([]SearchResult, 0), make([]string, 0)}
+
+	ignore, err := labignore.ForLab(labPath)
+	if err != nil {
+		return Search{}, &SearchError{err}
+	}
+
+	err = filepath.WalkDir(labPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if ignore.Ignores(path, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if filepath.Ext(path) != sidecarExtension {
+			return nil
+		}
+
+		videoPath := strings.TrimSuffix(path, sidecarExtension)
+		if ignore.Ignores(videoPath, false) || !fsutil.Exists(videoPath) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(labPath, videoPath)
+		if err != nil {
+			return err
+		}
+
+		markers, err := readMarkers(videoPath)
+		if err != nil {
+			// A corrupt markers file shouldn't prevent finding the others
+			search.Unreadable = append(search.Unreadable, filepath.ToSlash(rel))
+			return nil
+		}
+
+		for _, m := range markers {
+			if m.matches(query) {
+				search.Results = append(search.Results, SearchResult{filepath.ToSlash(rel), m})
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return Search{}, &SearchError{err}
+	}
+
+	slices.Sort(search.Unreadable)
+	slices.SortStableFunc(search.Results, func(a, b SearchResult) int {
+		if a.Video != b.Video {
+			return strings.Compare(a.Video, b.Video)
+		}
+
+		return cmp.Compare(a.Marker.Time, b.Marker.Time)
+	})
+
+	return search, nil
+}
+
+func (m Marker) validate() error {
+	if m.Time < 0 {
+		return ErrNegativeTime
+	}
+
+	if strings.TrimSpace(m.Note) == "" {
+		return ErrEmptyMarkerNote
+	}
+
+	return nil
+}
+
+func (m Marker) matches(lowerQuery string) bool {
+	if strings.Contains(strings.ToLower(m.Note), lowerQuery) {
+		return true
+	}
+
+	for _, l := range m.Links {
+		if strings.Contains(strings.ToLower(l.Graph), lowerQuery) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (mh *MarkerHandler) videoPath(pathFromLabRoot string) (string, error) {
+	p := filepath.Join(mh.GetLabPath(), pathFromLabRoot)
+	if node.DetectFileTypeFromPath(p) != node.VIDEO {
+		return "", ErrNotAVideo
+	}
+
+	return p, nil
+}
+
+// Reads the markers of the video located at the given absolute path, sorted by time
+func readMarkers(videoPath string) ([]Marker, error) {
+	b, err := os.ReadFile(videoPath + sidecarExtension)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Marker{}, nil
+	}
+
+	if err != nil {
+		return nil, &MarkersFileError{videoPath, err}
+	}
+
+	var mf markersFile
+	err = json.Unmarshal(b, &mf)
+	if err != nil {
+		return nil, &MarkersFileError{videoPath, err}
+	}
+
+	slices.SortStableFunc(mf.Markers, func(a, b Marker) int {
+		return cmp.Compare(a.Time, b.Time)
+	})
+
+	return mf.Markers, nil
+}
+
+func writeMarkers(videoPath string, markers []Marker) error {
+	if len(markers) == 0 {
+		err := os.Remove(videoPath + sidecarExtension)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return &MarkersFileError{videoPath, err}
+		}
+
+		return nil
+	}
+
+	slices.SortStableFunc(markers, func(a, b Marker) int {
+		return cmp.Compare(a.Time, b.Time)
+	})
+
+	b, err := json.MarshalIndent(markersFile{markers}, "", "\t")
+	if err != nil {
+		return &MarkersFileError{videoPath, err}
+	}
+
+	err = os.WriteFile(videoPath+sidecarExtension, b, 0644)
+	if err != nil {
+		return &MarkersFileError{videoPath, err}
+	}
+
+	return nil
+}
+
+func newMarkerId() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,227 @@
+package marker
+
+import (
+	"errors"
+	"flow-poc/backend/config"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// Creates a temporary lab holding the given empty videos
+func createTempLab(t testing.TB, videos ...string) (string, *MarkerHandler) {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "testMarkers")
+	if err != nil {
+		t.Fatalf("an error occured while creating temporary directory: %v", err)
+	}
+
+	for _, v := range videos {
+		p := filepath.Join(dir, v)
+		err := os.MkdirAll(filepath.Dir(p), os.ModePerm)
+		if err != nil {
+			t.Fatalf("couldn't create video directory: %v", err)
+		}
+
+		f, err := os.Create(p)
+		if err != nil {
+			t.Fatalf("couldn't create video: %v", err)
+		}
+		f.Close()
+	}
+
+	return dir, NewMarkerHandler(&config.AppConfig{
+		ConfigFile: config.ConfigFile{
+			LabPath: dir,
+		},
+	})
+}
+
+func addMarkerHelper(t testing.TB, mh *MarkerHandler, video string, time float64, note string) Marker {
+	t.Helper()
+
+	m, err := mh.AddMarker(video, Marker{Time: time, Note: note})
+	if err != nil {
+		t.Fatalf("couldn't add marker: %v", err)
+	}
+
+	return m
+}
+
+func TestAddAndListMarkers(t *testing.T) {
+	t.Run("markers are listed by time", func(t *testing.T) {
+		dir, mh := createTempLab(t, "session.webm")
+		defer os.RemoveAll(dir)
+
+		addMarkerHelper(t, mh, "session.webm", 33.0, "dropped combo")
+		addMarkerHelper(t, mh, "session.webm", 12.4, "whiff punish")
+
+		markers, err := mh.ListMarkers("session.webm")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(markers) != 2 {
+			t.Fatalf("want 2 markers, got %d", len(markers))
+		}
+
+		if markers[0].Note != "whiff punish" {
+			t.Errorf("markers are not sorted by time: %v", markers)
+		}
+	})
+
+	t.Run("markers added at the same time are all kept", func(t *testing.T) {
+		dir, mh := createTempLab(t, "session.webm")
+		defer os.RemoveAll(dir)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if _, err := mh.AddMarker("session.webm", Marker{Time: float64(i), Note: "test"}); err != nil {
+					t.Errorf("couldn't add marker: %v", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		markers, err := mh.ListMarkers("session.webm")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(markers) != 20 {
+			t.Errorf("want 20 markers, got %d", len(markers))
+		}
+	})
+
+	t.Run("markers can't be placed on images", func(t *testing.T) {
+		dir, mh := createTempLab(t, "capture.png")
+		defer os.RemoveAll(dir)
+
+		_, err := mh.AddMarker("capture.png", Marker{Time: 1, Note: "test"})
+		if !errors.Is(err, ErrNotAVideo) {
+			t.Errorf("got %v, want %v", err, ErrNotAVideo)
+		}
+	})
+
+	t.Run("markers with a negative time are refused", func(t *testing.T) {
+		dir, mh := createTempLab(t, "session.webm")
+		defer os.RemoveAll(dir)
+
+		_, err := mh.AddMarker("session.webm", Marker{Time: -1, Note: "test"})
+		if !errors.Is(err, ErrNegativeTime) {
+			t.Errorf("got %v, want %v", err, ErrNegativeTime)
+		}
+	})
+}
+
+func TestEditAndRemoveMarker(t *testing.T) {
+	t.Run("edit a marker", func(t *testing.T) {
+		dir, mh := createTempLab(t, "session.webm")
+		defer os.RemoveAll(dir)
+		m := addMarkerHelper(t, mh, "session.webm", 12.4, "whiff punish")
+
+		m.Note = "whiff punish 2S"
+		m.Links = []NodeLink{{Graph: "Sol/neutral.json", NodeId: "1"}}
+		err := mh.EditMarker("session.webm", m)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		markers, _ := mh.ListMarkers("session.webm")
+		if markers[0].Note != m.Note || len(markers[0].Links) != 1 {
+			t.Errorf("the marker was not edited: %v", markers[0])
+		}
+	})
+
+	t.Run("edit a marker that doesn't exist", func(t *testing.T) {
+		dir, mh := createTempLab(t, "session.webm")
+		defer os.RemoveAll(dir)
+
+		err := mh.EditMarker("session.webm", Marker{Id: "fake", Note: "test"})
+		if !errors.Is(err, ErrMarkerNotFound) {
+			t.Errorf("got %v, want %v", err, ErrMarkerNotFound)
+		}
+	})
+
+	t.Run("removing the last marker deletes the sidecar file", func(t *testing.T) {
+		dir, mh := createTempLab(t, "session.webm")
+		defer os.RemoveAll(dir)
+		m := addMarkerHelper(t, mh, "session.webm", 12.4, "whiff punish")
+
+		err := mh.RemoveMarker("session.webm", m.Id)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		_, err = os.Stat(filepath.Join(dir, "session.webm"+sidecarExtension))
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("the sidecar file should have been deleted: %v", err)
+		}
+	})
+}
+
+func TestSearchMarkers(t *testing.T) {
+	dir, mh := createTempLab(t, "session.webm", "Sol/training.mp4")
+	defer os.RemoveAll(dir)
+	addMarkerHelper(t, mh, "session.webm", 12.4, "Whiff punish")
+	addMarkerHelper(t, mh, "session.webm", 33.0, "dropped combo")
+	addMarkerHelper(t, mh, "Sol/training.mp4", 5, "punish on block")
+
+	search, err := mh.SearchMarkers("punish")
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if len(search.Results) != 2 {
+		t.Fatalf("want 2 results, got %d: %v", len(search.Results), search.Results)
+	}
+
+	if search.Results[0].Video != "Sol/training.mp4" {
+		t.Errorf("results are not sorted by video, got %s first", search.Results[0].Video)
+	}
+
+	all, err := mh.SearchMarkers("")
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if len(all.Results) != 3 {
+		t.Errorf("want 3 results, got %d", len(all.Results))
+	}
+
+	t.Run("skips markers of missing, ignored and corrupt files", func(t *testing.T) {
+		files := map[string]string{
+			// Video removed outside of the app
+			"deleted.webm.markers":     `{"markers":[{"id":"a","time":1,"note":"punish"}]}`,
+			"corrupt.webm":             "",
+			"corrupt.webm.markers":     "{",
+			".hidden/old.webm":         "",
+			".hidden/old.webm.markers": `{"markers":[{"id":"b","time":1,"note":"punish"}]}`,
+		}
+		for p, content := range files {
+			os.MkdirAll(filepath.Dir(filepath.Join(dir, p)), os.ModePerm)
+			if err := os.WriteFile(filepath.Join(dir, p), []byte(content), 0644); err != nil {
+				t.Fatalf("couldn't create %s: %v", p, err)
+			}
+		}
+
+		search, err := mh.SearchMarkers("punish")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(search.Results) != 2 {
+			t.Errorf("want 2 results, got %d: %v", len(search.Results), search.Results)
+		}
+
+		if !slices.Equal(search.Unreadable, []string{"corrupt.webm"}) {
+			t.Errorf("got unreadable files %v, want [corrupt.webm]", search.Unreadable)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package moves
+
+import (
+	"context"
+	"errors"
+	"flow-poc/backend/db"
+	"flow-poc/backend/db/repository"
+	"fmt"
+)
+
+// How a move must be blocked
+type Guard string
+
+const (
+	ALL         Guard = "ALL"
+	HIGH        Guard = "HIGH"
+	LOW         Guard = "LOW"
+	UNBLOCKABLE Guard = "UNBLOCKABLE"
+)
+
+var Guards = []struct {
+	Value  Guard
+	TSName string
+}{
+	{ALL, "ALL"},
+	{HIGH, "HIGH"},
+	{LOW, "LOW"},
+	{UNBLOCKABLE, "UNBLOCKABLE"},
+}
+
+func (g Guard) valid() bool {
+	for _, guard := range Guards {
+		if guard.Value == g {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	ErrUnknownGuard   = errors.New("unknown guard")
+	ErrNegativeFrames = errors.New("startup, active and recovery frames can't be negative")
+	ErrNegativeReach  = errors.New("the reach of a move can't be negative")
+)
+
+// Moves belong to a character and are deleted along with it. Frame advantages are
+// given from the point of view of the attacker: -5 on block means the defender
+// recovers 5 frames before the attacker. The reach of a move is how far it hits, in the unit
+// chosen for the game, 0 when unknown
+type MoveRepository struct {
+	conn *db.Conn
+}
+
+func NewMoveRepository(conn *db.Conn) *MoveRepository {
+	return &MoveRepository{
+		conn,
+	}
+}
+
+func checkMove(guard string, startup, active, recovery, reach int64) error {
+	if !Guard(guard).valid() {
+		return fmt.Errorf("%w %q", ErrUnknownGuard, guard)
+	}
+
+	if startup < 0 || active < 0 || recovery < 0 {
+		return ErrNegativeFrames
+	}
+
+	if reach < 0 {
+		return ErrNegativeReach
+	}
+
+	return nil
+}
+
+func (mr *MoveRepository) AddMove(newMove repository.AddMoveParams) (repository.Move, error) {
+	ctx := context.Background()
+	err := checkMove(newMove.Guard, newMove.Startup, newMove.Active, newMove.Recovery, newMove.Reach)
+	if err != nil {
+		return repository.Move{}, err
+	}
+
+	q, err := mr.conn.Queries()
+	if err != nil {
+		return repository.Move{}, err
+	}
+
+	move, err := q.AddMove(ctx, newMove)
+	if err != nil {
+		return repository.Move{}, err
+	}
+
+	return move, nil
+}
+
+func (mr *MoveRepository) GetOneMove(id int64) (repository.Move, error) {
+	ctx := context.Background()
+	q, err := mr.conn.Queries()
+	if err != nil {
+		return repository.Move{}, err
+	}
+
+	move, err := q.GetOneMove(ctx, id)
+	if err != nil {
+		return repository.Move{}, err
+	}
+
+	return move, nil
+}
+
+// Returns the moves of a character, the fastest first
+func (mr *MoveRepository) ListMoves(characterId int64) ([]repository.Move, error) {
+	ctx := context.Background()
+	q, err := mr.conn.Queries()
+	if err != nil {
+		return []repository.Move{}, err
+	}
+
+	moves, err := q.ListMoves(ctx, characterId)
+	if err != nil {
+		return []repository.Move{}, err
+	}
+
+	return moves, err
+}
+
+// Returns the moves of a character that are at least frames frames negative on block,
+// the most negative first. ListPunishableMoves(id, 10) returns the moves that are -10
+// or worse on block
+func (mr *MoveRepository) ListPunishableMoves(characterId, frames int64) ([]repository.Move, error) {
+	ctx := context.Background()
+	q, err := mr.conn.Queries()
+	if err != nil {
+		return []repository.Move{}, err
+	}
+
+	moves, err := q.ListPunishableMoves(ctx, repository.ListPunishableMovesParams{
+		Characterid: characterId,
+		Onblock:     -frames,
+	})
+	if err != nil {
+		return []repository.Move{}, err
+	}
+
+	return moves, err
+}
+
+// Returns the moves of a character starting in at most startup frames that reach at least
+// reach, the fastest first. Moves without startup are left out, moves whose reach is unknown
+// are kept since they may be in range
+func (mr *MoveRepository) ListMovesStartingWithin(characterId, startup, reach int64) ([]repository.Move, error) {
+	ctx := context.Background()
+	q, err := mr.conn.Queries()
+	if err != nil {
+		return []repository.Move{}, err
+	}
+
+	moves, err := q.ListMovesStartingWithin(ctx, repository.ListMovesStartingWithinParams{
+		Characterid: characterId,
+		Startup:     startup,
+		Reach:       reach,
+	})
+	if err != nil {
+		return []repository.Move{}, err
+	}
+
+	return moves, err
+}
+
+// Edits everything but the character of the move
+func (mr *MoveRepository) UpdateMove(editedMove repository.EditMoveParams) error {
+	ctx := context.Background()
+	err := checkMove(editedMove.Guard, editedMove.Startup, editedMove.Active, editedMove.Recovery, editedMove.Reach)
+	if err != nil {
+		return err
+	}
+
+	q, err := mr.conn.Queries()
+	if err != nil {
+		return err
+	}
+
+	err = q.EditMove(ctx, editedMove)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+func (mr *MoveRepository) DeleteMove(id int64) error {
+	ctx := context.Background()
+	q, err := mr.conn.Queries()
+	if err != nil {
+		return err
+	}
+
+	err = q.DeleteMove(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
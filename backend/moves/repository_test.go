@@ -0,0 +1,125 @@
+package moves
+
+import (
+	"errors"
+	"flow-poc/backend/characters"
+	"flow-poc/backend/db"
+	"flow-poc/backend/db/dbtest"
+	"flow-poc/backend/db/repository"
+	"flow-poc/backend/games"
+	"testing"
+)
+
+func addCharacter(t testing.TB, conn *db.Conn) repository.Character {
+	t.Helper()
+
+	game, err := games.NewGameRepository(conn).AddGame(repository.AddGameParams{Name: "Guilty Gear Strive"})
+	if err != nil {
+		t.Fatalf("couldn't add game: %v", err)
+	}
+
+	sol, err := characters.NewCharacterRepository(conn).AddCharacter(repository.AddCharacterParams{Gameid: game.ID, Name: "Sol Badguy"})
+	if err != nil {
+		t.Fatalf("couldn't add character: %v", err)
+	}
+
+	return sol
+}
+
+func TestMoveRepository(t *testing.T) {
+	conn := dbtest.OpenTemp(t)
+	sol := addCharacter(t, conn)
+	mr := NewMoveRepository(conn)
+
+	for _, m := range []repository.AddMoveParams{
+		{Characterid: sol.ID, Notation: "5P", Startup: 4, Active: 3, Recovery: 9, Onblock: -1, Damage: 24, Guard: string(ALL), Reach: 150},
+		{Characterid: sol.ID, Notation: "2D", Startup: 10, Active: 3, Recovery: 20, Onblock: -12, Damage: 28, Guard: string(LOW), Reach: 280},
+		{Characterid: sol.ID, Notation: "623H", Name: "Volcanic Viper", Startup: 9, Active: 14, Recovery: 37, Onblock: -46, Damage: 55, Guard: string(ALL), Invulnerable: "1-12 all", Reach: 120},
+	} {
+		if _, err := mr.AddMove(m); err != nil {
+			t.Fatalf("couldn't add move %s: %v", m.Notation, err)
+		}
+	}
+
+	t.Run("moves are listed fastest first", func(t *testing.T) {
+		moves, err := mr.ListMoves(sol.ID)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(moves) != 3 || moves[0].Notation != "5P" || moves[2].Notation != "2D" {
+			t.Errorf("wrong moves: %+v", moves)
+		}
+	})
+
+	t.Run("punishable moves", func(t *testing.T) {
+		moves, err := mr.ListPunishableMoves(sol.ID, 12)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(moves) != 2 || moves[0].Notation != "623H" || moves[1].Notation != "2D" {
+			t.Errorf("got %+v, want the moves -12 or worse on block", moves)
+		}
+	})
+
+	t.Run("moves fast enough and in range", func(t *testing.T) {
+		moves, err := mr.ListMovesStartingWithin(sol.ID, 9, 130)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(moves) != 1 || moves[0].Notation != "5P" {
+			t.Errorf("got %+v, want the moves starting in 9 frames reaching 130", moves)
+		}
+	})
+
+	t.Run("moves whose reach is unknown are kept", func(t *testing.T) {
+		_, err := mr.AddMove(repository.AddMoveParams{Characterid: sol.ID, Notation: "6P", Startup: 9, Guard: string(ALL)})
+		if err != nil {
+			t.Fatalf("couldn't add move: %v", err)
+		}
+
+		moves, err := mr.ListMovesStartingWithin(sol.ID, 9, 130)
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(moves) != 2 || moves[0].Notation != "5P" || moves[1].Notation != "6P" {
+			t.Errorf("got %+v, want 5P and the move without reach", moves)
+		}
+	})
+
+	t.Run("invalid moves", func(t *testing.T) {
+		_, err := mr.AddMove(repository.AddMoveParams{Characterid: sol.ID, Notation: "6H", Guard: "OVERHEAD"})
+		if !errors.Is(err, ErrUnknownGuard) {
+			t.Errorf("got %v, want %v", err, ErrUnknownGuard)
+		}
+
+		_, err = mr.AddMove(repository.AddMoveParams{Characterid: sol.ID, Notation: "6H", Guard: string(HIGH), Startup: -1})
+		if !errors.Is(err, ErrNegativeFrames) {
+			t.Errorf("got %v, want %v", err, ErrNegativeFrames)
+		}
+
+		_, err = mr.AddMove(repository.AddMoveParams{Characterid: sol.ID, Notation: "6H", Guard: string(HIGH), Reach: -1})
+		if !errors.Is(err, ErrNegativeReach) {
+			t.Errorf("got %v, want %v", err, ErrNegativeReach)
+		}
+
+		_, err = mr.AddMove(repository.AddMoveParams{Characterid: sol.ID, Notation: "5P", Guard: string(ALL)})
+		if err == nil {
+			t.Errorf("a notation should be unique for a character")
+		}
+	})
+
+	t.Run("deleting a character deletes its moves", func(t *testing.T) {
+		if err := characters.NewCharacterRepository(conn).DeleteCharacter(sol.ID); err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		moves, err := mr.ListMoves(sol.ID)
+		if err != nil || len(moves) != 0 {
+			t.Errorf("the moves of the character should have been deleted: %+v, %v", moves, err)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+// This package finds the best punishes of a character against an opponent's move. A move
+// can be punished by any move starting before the opponent recovers and reaching them. Each
+// of these moves is a punish on its own, and so is every stored combo starting with one of
+// them. Punishes are ranked by the priorities the user set for the game.
+package punish
+
+import (
+	"context"
+	"flow-poc/backend/db"
+	"flow-poc/backend/db/repository"
+	"slices"
+)
+
+// The opponent's move to punish
+type OpponentMove struct {
+	// Frame advantage of the move on block, -12 for a move leaving the opponent 12 frames
+	// to recover
+	OnBlock int64 `json:"onBlock"`
+	// Distance between the characters once the move is blocked, in the unit of the moves'
+	// reach. 0 when unknown, then every move is considered in range
+	Distance int64 `json:"distance"`
+}
+
+// A move, alone or followed by a combo, that punishes the opponent's move
+type Punish struct {
+	Move repository.Move `json:"move"`
+	// Combo landed after the move, nil when the move is the whole punish
+	Combo       *repository.Combo `json:"combo"`
+	Damage      int64             `json:"damage"`
+	CornerCarry int64             `json:"cornerCarry"`
+	// Frame advantage once the punish is over
+	Oki   int64 `json:"oki"`
+	Meter int64 `json:"meter"`
+	// Weighted sum of the values above, relative to the other punishes found
+	Score float64 `json:"score"`
+}
+
+type PunishFinder struct {
+	conn *db.Conn
+}
+
+func NewPunishFinder(conn *db.Conn) *PunishFinder {
+	return &PunishFinder{
+		conn,
+	}
+}
+
+// Returns the punishes of a character against a move, the best first according to the
+// priorities of the character's game. A move that's safe on block has no punish
+func (pf *PunishFinder) FindPunishes(characterId int64, opponent OpponentMove) ([]Punish, error) {
+	ctx := context.Background()
+	q, err := pf.conn.Queries()
+	if err != nil {
+		return []Punish{}, err
+	}
+
+	character, err := q.GetOneCharacter(ctx, characterId)
+	if err != nil {
+		return []Punish{}, err
+	}
+
+	if opponent.OnBlock >= 0 {
+		return []Punish{}, nil
+	}
+
+	moves, err := q.ListMovesStartingWithin(ctx, repository.ListMovesStartingWithinParams{
+		Characterid: characterId,
+		Startup:     -opponent.OnBlock,
+		Reach:       max(opponent.Distance, 0),
+	})
+	if err != nil {
+		return []Punish{}, err
+	}
+
+	combos, err := q.ListCombos(ctx, characterId)
+	if err != nil {
+		return []Punish{}, err
+	}
+
+	p, err := priorities(ctx, q, character.Gameid)
+	if err != nil {
+		return []Punish{}, err
+	}
+
+	return rank(candidates(moves, combos), p), nil
+}
+
+// Returns every move as a punish, then every combo starting with one of the moves
+func candidates(moves []repository.Move, combos []repository.Combo) []Punish {
+	punishes := make([]Punish, 0, len(moves))
+	starters := make(map[int64]repository.Move, len(moves))
+	for _, m := range moves {
+		starters[m.ID] = m
+		punishes = append(punishes, Punish{
+			Move:   m,
+			Damage: m.Damage,
+			Oki:    m.Onhit,
+		})
+	}
+
+	for _, c := range combos {
+		c := c
+		m, found := starters[c.Starterid]
+		if !found {
+			continue
+		}
+
+		punishes = append(punishes, Punish{
+			Move:        m,
+			Combo:       &c,
+			Damage:      c.Damage,
+			CornerCarry: c.Cornercarry,
+			Oki:         c.Oki,
+			Meter:       c.Meter,
+		})
+	}
+
+	return punishes
+}
+
+// Scores the punishes and sorts them, the best first. Each value is scaled between 0 and 1
+// among the punishes found so the weights compare criteria with different units. Spending
+// meter lowers the score. Ties go to the most damaging punish, then the fastest one
+func rank(punishes []Punish, p repository.PunishPriority) []Punish {
+	damage := scale(punishes, func(pu Punish) int64 { return pu.Damage })
+	carry := scale(punishes, func(pu Punish) int64 { return pu.CornerCarry })
+	oki := scale(punishes, func(pu Punish) int64 { return pu.Oki })
+	meter := scale(punishes, func(pu Punish) int64 { return pu.Meter })
+
+	for i := range punishes {
+		punishes[i].Score = p.Damage*damage[i] + p.Cornercarry*carry[i] + p.Oki*oki[i] - p.Meter*meter[i]
+	}
+
+	slices.SortStableFunc(punishes, func(a, b Punish) int {
+		switch {
+		case a.Score != b.Score:
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		case a.Damage != b.Damage:
+			return int(b.Damage - a.Damage)
+		default:
+			return int(a.Move.Startup - b.Move.Startup)
+		}
+	})
+
+	return punishes
+}
+
+func scale(punishes []Punish, value func(Punish) int64) []float64 {
+	scaled := make([]float64, len(punishes))
+	if len(punishes) == 0 {
+		return scaled
+	}
+
+	lowest, highest := value(punishes[0]), value(punishes[0])
+	for _, pu := range punishes {
+		lowest = min(lowest, value(pu))
+		highest = max(highest, value(pu))
+	}
+
+	if lowest == highest {
+		return scaled
+	}
+
+	for i, pu := range punishes {
+		scaled[i] = float64(value(pu)-lowest) / float64(highest-lowest)
+	}
+
+	return scaled
+}
@@ -0,0 +1,159 @@
+package punish
+
+import (
+	"context"
+	"errors"
+	"flow-poc/backend/db"
+	"flow-poc/backend/db/dbtest"
+	"flow-poc/backend/db/repository"
+	"slices"
+	"testing"
+)
+
+var ctx = context.Background()
+
+// Adds Sol with a few moves and combos and returns the ids of the game and of Sol
+func addSol(t testing.TB, conn *db.Conn) (int64, int64) {
+	t.Helper()
+
+	q, err := conn.Queries()
+	if err != nil {
+		t.Fatalf("couldn't get queries: %v", err)
+	}
+
+	game, err := q.AddGame(ctx, repository.AddGameParams{Name: "Guilty Gear Strive"})
+	if err != nil {
+		t.Fatalf("couldn't add game: %v", err)
+	}
+
+	sol, err := q.AddCharacter(ctx, repository.AddCharacterParams{Gameid: game.ID, Name: "Sol Badguy"})
+	if err != nil {
+		t.Fatalf("couldn't add character: %v", err)
+	}
+
+	moves := make(map[string]int64)
+	for _, m := range []repository.AddMoveParams{
+		{Notation: "5K", Startup: 5, Onhit: 2, Damage: 25, Reach: 150},
+		{Notation: "f.S", Startup: 7, Onhit: 3, Damage: 31, Reach: 180},
+		{Notation: "6H", Startup: 18, Onhit: 0, Damage: 50, Reach: 200},
+	} {
+		m.Characterid = sol.ID
+		m.Guard = "ALL"
+		move, err := q.AddMove(ctx, m)
+		if err != nil {
+			t.Fatalf("couldn't add move %s: %v", m.Notation, err)
+		}
+		moves[m.Notation] = move.ID
+	}
+
+	for _, c := range []repository.AddComboParams{
+		{Starterid: moves["5K"], Name: "Meterless", Damage: 120, Cornercarry: 20, Oki: 30},
+		{Starterid: moves["f.S"], Name: "Corner carry", Damage: 130, Cornercarry: 90, Oki: 10},
+		{Starterid: moves["f.S"], Name: "Tyrant Rave", Damage: 200, Cornercarry: 40, Oki: 0, Meter: 50},
+		{Starterid: moves["6H"], Name: "Too slow", Damage: 300},
+	} {
+		c.Characterid = sol.ID
+		if _, err := q.AddCombo(ctx, c); err != nil {
+			t.Fatalf("couldn't add combo %s: %v", c.Name, err)
+		}
+	}
+
+	return game.ID, sol.ID
+}
+
+func names(punishes []Punish) []string {
+	n := make([]string, 0, len(punishes))
+	for _, p := range punishes {
+		if p.Combo == nil {
+			n = append(n, p.Move.Notation)
+		} else {
+			n = append(n, p.Combo.Name)
+		}
+	}
+
+	return n
+}
+
+func TestFindPunishes(t *testing.T) {
+	conn := dbtest.OpenTemp(t)
+	gameId, sol := addSol(t, conn)
+	pf := NewPunishFinder(conn)
+
+	t.Run("only moves fast enough and in range", func(t *testing.T) {
+		punishes, err := pf.FindPunishes(sol, OpponentMove{OnBlock: -7, Distance: 160})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		got := names(punishes)
+		slices.Sort(got)
+		if want := []string{"Corner carry", "Tyrant Rave", "f.S"}; slices.Compare(got, want) != 0 {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("a safe move has no punish", func(t *testing.T) {
+		punishes, err := pf.FindPunishes(sol, OpponentMove{OnBlock: 0})
+		if err != nil || len(punishes) != 0 {
+			t.Errorf("got %v, %v, want no punish", names(punishes), err)
+		}
+	})
+
+	t.Run("ranked by the game's priorities", func(t *testing.T) {
+		err := pf.SavePriorities(repository.SavePunishPrioritiesParams{Gameid: gameId, Damage: 1})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		punishes, err := pf.FindPunishes(sol, OpponentMove{OnBlock: -10})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if got := names(punishes); got[0] != "Tyrant Rave" {
+			t.Errorf("the most damaging punish should come first: %v", got)
+		}
+
+		err = pf.SavePriorities(repository.SavePunishPrioritiesParams{Gameid: gameId, Damage: 0.2, Cornercarry: 1, Meter: 1})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		punishes, err = pf.FindPunishes(sol, OpponentMove{OnBlock: -10})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if got := names(punishes); got[0] != "Corner carry" {
+			t.Errorf("the punish carrying the most without meter should come first: %v", got)
+		}
+	})
+
+	t.Run("priorities", func(t *testing.T) {
+		if err := pf.SavePriorities(repository.SavePunishPrioritiesParams{Gameid: gameId, Oki: -1}); !errors.Is(err, ErrNegativePriority) {
+			t.Errorf("got %v, want %v", err, ErrNegativePriority)
+		}
+
+		p, err := pf.GetPriorities(gameId + 1)
+		if err != nil || p != DefaultPriorities(gameId+1) {
+			t.Errorf("a game without priorities should get the default ones, got %+v, %v", p, err)
+		}
+	})
+}
+
+func TestRank(t *testing.T) {
+	punishes := []Punish{
+		{Move: repository.Move{Notation: "slow", Startup: 9}, Damage: 50},
+		{Move: repository.Move{Notation: "fast", Startup: 4}, Damage: 50},
+		{Move: repository.Move{Notation: "weak", Startup: 4}, Damage: 10},
+	}
+
+	got := names(rank(punishes, DefaultPriorities(1)))
+	if want := []string{"fast", "slow", "weak"}; slices.Compare(got, want) != 0 {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if rank([]Punish{}, DefaultPriorities(1)) == nil {
+		t.Errorf("no punish should give an empty list")
+	}
+}
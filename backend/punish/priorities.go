@@ -0,0 +1,56 @@
+package punish
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flow-poc/backend/db/repository"
+)
+
+var ErrNegativePriority = errors.New("priorities can't be negative")
+
+// Priorities used for a game that has none saved: damage first, then corner carry, oki
+// and saving meter equally
+func DefaultPriorities(gameId int64) repository.PunishPriority {
+	return repository.PunishPriority{
+		Gameid:      gameId,
+		Damage:      1,
+		Cornercarry: 0.5,
+		Oki:         0.5,
+		Meter:       0.5,
+	}
+}
+
+// Returns the weights used to rank the punishes of a game
+func (pf *PunishFinder) GetPriorities(gameId int64) (repository.PunishPriority, error) {
+	q, err := pf.conn.Queries()
+	if err != nil {
+		return repository.PunishPriority{}, err
+	}
+
+	return priorities(context.Background(), q, gameId)
+}
+
+// Saves the weights used to rank the punishes of a game. A weight of 0 ignores the criteria.
+// The meter weight favors punishes spending less meter
+func (pf *PunishFinder) SavePriorities(p repository.SavePunishPrioritiesParams) error {
+	if p.Damage < 0 || p.Cornercarry < 0 || p.Oki < 0 || p.Meter < 0 {
+		return ErrNegativePriority
+	}
+
+	q, err := pf.conn.Queries()
+	if err != nil {
+		return err
+	}
+
+	return q.SavePunishPriorities(context.Background(), p)
+}
+
+func priorities(ctx context.Context, q *repository.Queries, gameId int64) (repository.PunishPriority, error) {
+	p, err := q.GetPunishPriorities(ctx, gameId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultPriorities(gameId), nil
+	}
+
+	return p, err
+}
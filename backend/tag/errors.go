@@ -0,0 +1,28 @@
+package tag
+
+import "fmt"
+
+type TagsFileError struct {
+	path string
+	err  error
+}
+
+func (t *TagsFileError) Error() string {
+	return fmt.Sprintf("couldn't access tags of %s: %v", t.path, t.err)
+}
+
+func (t *TagsFileError) Unwrap() error {
+	return t.err
+}
+
+type TagSearchError struct {
+	err error
+}
+
+func (t *TagSearchError) Error() string {
+	return fmt.Sprintf("couldn't search tags: %v", t.err)
+}
+
+func (t *TagSearchError) Unwrap() error {
+	return t.err
+}
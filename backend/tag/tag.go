@@ -0,0 +1,383 @@
+// This package handles the tags put on the lab's files, like a game, a character, a matchup
+// or "à revoir". Tags of a graph are stored in its metadata, tags of any other file are
+// stored in a sidecar file next to it.
+package tag
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/labignore"
+	"flow-poc/backend/filesystem/node"
+	"flow-poc/backend/graph"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Extension appended to a file's name to get its tags file
+var sidecarExtension = node.SidecarExtension(node.TAGS)
+
+var (
+	ErrNotTaggable = errors.New("only files can be tagged")
+	ErrEmptyTag    = errors.New("a tag can't be empty")
+)
+
+// A file of the lab along with its tags
+type TaggedNode struct {
+	node.Node
+	// Path to the file starting from the lab root
+	Path string   `json:"path"`
+	Tags []string `json:"tags"`
+}
+
+// A tag used in the lab and the number of files carrying it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+type tagsFile struct {
+	Tags []string `json:"tags"`
+}
+
+type TagHandler struct {
+	Cfg *config.AppConfig
+	// Serializes the changes to tags so concurrent calls don't lose each other's tags
+	mu sync.Mutex
+}
+
+func NewTagHandler(cfg *config.AppConfig) *TagHandler {
+	return &TagHandler{
+		Cfg: cfg,
+	}
+}
+
+func (th *TagHandler) GetLabPath() string {
+	return th.Cfg.LabPath()
+}
+
+// Returns the tags of a file. A file without tags returns an empty list
+func (th *TagHandler) GetTags(pathFromLabRoot string) ([]string, error) {
+	p, err := th.taggablePath(pathFromLabRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return readTags(p)
+}
+
+// Replaces the tags of a file. Tags are trimmed and duplicates are removed without
+// taking case into account. Returns the tags as they were saved
+func (th *TagHandler) SetTags(pathFromLabRoot string, tags []string) ([]string, error) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	return th.setTags(pathFromLabRoot, tags)
+}
+
+func (th *TagHandler) setTags(pathFromLabRoot string, tags []string) ([]string, error) {
+	p, err := th.taggablePath(pathFromLabRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err = normalize(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, writeTags(p, tags)
+}
+
+// Adds a tag to a file if it doesn't already carry it
+func (th *TagHandler) AddTag(pathFromLabRoot, tag string) ([]string, error) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	tags, err := th.GetTags(pathFromLabRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return th.setTags(pathFromLabRoot, append(tags, tag))
+}
+
+// Removes a tag from a file, case insensitively
+func (th *TagHandler) RemoveTag(pathFromLabRoot, tag string) ([]string, error) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	tags, err := th.GetTags(pathFromLabRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	tags = slices.DeleteFunc(tags, func(t string) bool {
+		return strings.EqualFold(t, strings.TrimSpace(tag))
+	})
+
+	return th.setTags(pathFromLabRoot, tags)
+}
+
+// Returns every tag used in the lab with the number of files carrying it, most used first
+func (th *TagHandler) ListTags() ([]TagCount, error) {
+	files, err := th.taggedFiles()
+	if err != nil {
+		return nil, &TagSearchError{err}
+	}
+
+	counts := make(map[string]*TagCount)
+	for _, f := range files {
+		for _, t := range f.Tags {
+			k := strings.ToLower(t)
+			if _, ok := counts[k]; !ok {
+				counts[k] = &TagCount{Tag: t}
+			}
+			counts[k].Count++
+		}
+	}
+
+	list := make([]TagCount, 0, len(counts))
+	for _, c := range counts {
+		list = append(list, *c)
+	}
+
+	slices.SortFunc(list, func(a, b TagCount) int {
+		if a.Count != b.Count {
+			return cmp.Compare(b.Count, a.Count)
+		}
+
+		return strings.Compare(strings.ToLower(a.Tag), strings.ToLower(b.Tag))
+	})
+
+	return list, nil
+}
+
+// Returns every file carrying all the given tags, sorted by path. The comparison is case
+// insensitive. Without tags, every tagged file is returned
+func (th *TagHandler) FindByTags(tags []string) ([]TaggedNode, error) {
+	files, err := th.taggedFiles()
+	if err != nil {
+		return nil, &TagSearchError{err}
+	}
+
+	return slices.DeleteFunc(files, func(f TaggedNode) bool {
+		for _, t := range tags {
+			if !slices.ContainsFunc(f.Tags, func(ft string) bool {
+				return strings.EqualFold(ft, strings.TrimSpace(t))
+			}) {
+				return true
+			}
+		}
+
+		return false
+	}), nil
+}
+
+// Walks through the lab and returns every file that has at least one tag
+func (th *TagHandler) taggedFiles() ([]TaggedNode, error) {
+	labPath := th.GetLabPath()
+	ignore, err := labignore.ForLab(labPath)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]TaggedNode, 0)
+	err = filepath.WalkDir(labPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if ignore.Ignores(path, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Tags of graphs are in the graphs, tags of other files are found through their sidecar
+		var filePath string
+		switch {
+		case filepath.Ext(path) == sidecarExtension:
+			filePath = strings.TrimSuffix(path, sidecarExtension)
+		case node.DetectFileTypeFromPath(path) == node.GRAPH:
+			filePath = path
+		default:
+			return nil
+		}
+
+		if ignore.Ignores(filePath, false) {
+			return nil
+		}
+
+		tags, err := readTags(filePath)
+		if err != nil || len(tags) == 0 {
+			// A graph that can't be parsed shouldn't prevent finding the others
+			return nil
+		}
+
+		info, err := os.Stat(filePath)
+		if err != nil {
+			// Sidecar of a file that doesn't exist anymore
+			return nil
+		}
+
+		nodes, err := node.CreateNodesFromDirEntries(filepath.Dir(filePath), []fs.DirEntry{fs.FileInfoToDirEntry(info)})
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(labPath, filePath)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, TaggedNode{*nodes[0], filepath.ToSlash(rel), tags})
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(files, func(a, b TaggedNode) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return files, nil
+}
+
+func (th *TagHandler) taggablePath(pathFromLabRoot string) (string, error) {
+	p := filepath.Join(th.GetLabPath(), pathFromLabRoot)
+	info, err := os.Stat(p)
+	if err != nil {
+		return "", &TagsFileError{pathFromLabRoot, err}
+	}
+
+	if info.IsDir() || node.IsSidecar(p) {
+		return "", &TagsFileError{pathFromLabRoot, ErrNotTaggable}
+	}
+
+	return p, nil
+}
+
+func isGraph(absPath string) bool {
+	return node.DetectFileTypeFromPath(absPath) == node.GRAPH
+}
+
+// Reads the tags of the file located at the given absolute path
+func readTags(absPath string) ([]string, error) {
+	if isGraph(absPath) {
+		b, err := os.ReadFile(absPath)
+		if err != nil {
+			return nil, &TagsFileError{absPath, err}
+		}
+
+		var g graph.Graph
+		if err := json.Unmarshal(b, &g); err != nil {
+			return nil, &TagsFileError{absPath, err}
+		}
+
+		if g.Metadata == nil || g.Metadata.Tags == nil {
+			return []string{}, nil
+		}
+
+		return g.Metadata.Tags, nil
+	}
+
+	b, err := os.ReadFile(absPath + sidecarExtension)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+
+	if err != nil {
+		return nil, &TagsFileError{absPath, err}
+	}
+
+	var tf tagsFile
+	if err := json.Unmarshal(b, &tf); err != nil {
+		return nil, &TagsFileError{absPath, err}
+	}
+
+	return tf.Tags, nil
+}
+
+// Saves the tags of a file. The sidecar of a file is deleted along with its last tag
+func writeTags(absPath string, tags []string) error {
+	if isGraph(absPath) {
+		return writeGraphTags(absPath, tags)
+	}
+
+	if len(tags) == 0 {
+		err := os.Remove(absPath + sidecarExtension)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return &TagsFileError{absPath, err}
+		}
+
+		return nil
+	}
+
+	b, err := json.MarshalIndent(tagsFile{tags}, "", "\t")
+	if err != nil {
+		return &TagsFileError{absPath, err}
+	}
+
+	err = os.WriteFile(absPath+sidecarExtension, b, 0644)
+	if err != nil {
+		return &TagsFileError{absPath, err}
+	}
+
+	return nil
+}
+
+func writeGraphTags(absPath string, tags []string) error {
+	b, err := os.ReadFile(absPath)
+	if err != nil {
+		return &TagsFileError{absPath, err}
+	}
+
+	var g graph.Graph
+	if err := json.Unmarshal(b, &g); err != nil {
+		return &TagsFileError{absPath, err}
+	}
+
+	if g.Metadata == nil {
+		g.Metadata = &graph.GraphMetadata{}
+	}
+	g.Metadata.Tags = tags
+
+	b, err = json.MarshalIndent(g, "", "\t")
+	if err != nil {
+		return &TagsFileError{absPath, err}
+	}
+
+	err = os.WriteFile(absPath, b, 0644)
+	if err != nil {
+		return &TagsFileError{absPath, err}
+	}
+
+	return nil
+}
+
+func normalize(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return nil, ErrEmptyTag
+		}
+
+		if slices.ContainsFunc(normalized, func(n string) bool { return strings.EqualFold(n, t) }) {
+			continue
+		}
+
+		normalized = append(normalized, t)
+	}
+
+	return normalized, nil
+}
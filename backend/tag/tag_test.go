@@ -0,0 +1,175 @@
+package tag
+
+import (
+	"encoding/json"
+	"errors"
+	"flow-poc/backend/config"
+	"flow-poc/backend/graph"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Creates a temporary lab holding the given files. Files ending with .json are empty graphs
+func createTempLab(t testing.TB, files ...string) (string, *TagHandler) {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "testTags")
+	if err != nil {
+		t.Fatalf("an error occured while creating temporary directory: %v", err)
+	}
+
+	for _, f := range files {
+		p := filepath.Join(dir, f)
+		err := os.MkdirAll(filepath.Dir(p), os.ModePerm)
+		if err != nil {
+			t.Fatalf("couldn't create directory: %v", err)
+		}
+
+		var content []byte
+		if filepath.Ext(f) == ".json" {
+			content, _ = json.Marshal(graph.GetInitGraph())
+		}
+
+		err = os.WriteFile(p, content, 0644)
+		if err != nil {
+			t.Fatalf("couldn't create file: %v", err)
+		}
+	}
+
+	return dir, NewTagHandler(&config.AppConfig{
+		ConfigFile: config.ConfigFile{
+			LabPath: dir,
+		},
+	})
+}
+
+func setTagsHelper(t testing.TB, th *TagHandler, path string, tags ...string) {
+	t.Helper()
+
+	_, err := th.SetTags(path, tags)
+	if err != nil {
+		t.Fatalf("couldn't set tags: %v", err)
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	t.Run("tags of a graph are stored in its metadata", func(t *testing.T) {
+		dir, th := createTempLab(t, "Sol/oki.json")
+		defer os.RemoveAll(dir)
+
+		tags, err := th.SetTags("Sol/oki.json", []string{" Sol ", "oki", "sol"})
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(tags) != 2 || tags[0] != "Sol" {
+			t.Errorf("tags were not normalized: %v", tags)
+		}
+
+		b, _ := os.ReadFile(filepath.Join(dir, "Sol", "oki.json"))
+		var g graph.Graph
+		json.Unmarshal(b, &g)
+		if g.Metadata == nil || len(g.Metadata.Tags) != 2 || len(g.Nodes) != 1 {
+			t.Errorf("the graph was not saved correctly: %+v", g)
+		}
+	})
+
+	t.Run("tags of a media are stored in a sidecar", func(t *testing.T) {
+		dir, th := createTempLab(t, "session.webm")
+		defer os.RemoveAll(dir)
+
+		setTagsHelper(t, th, "session.webm", "à revoir")
+		if _, err := os.Stat(filepath.Join(dir, "session.webm"+sidecarExtension)); err != nil {
+			t.Fatalf("the sidecar was not created: %v", err)
+		}
+
+		tags, err := th.RemoveTag("session.webm", "À REVOIR")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(tags) != 0 {
+			t.Errorf("the tag was not removed: %v", tags)
+		}
+
+		_, err = os.Stat(filepath.Join(dir, "session.webm"+sidecarExtension))
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("the sidecar should have been deleted: %v", err)
+		}
+	})
+
+	t.Run("tags added at the same time are all kept", func(t *testing.T) {
+		dir, th := createTempLab(t, "session.webm")
+		defer os.RemoveAll(dir)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if _, err := th.AddTag("session.webm", fmt.Sprint("tag ", i)); err != nil {
+					t.Errorf("couldn't add tag: %v", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		tags, err := th.GetTags("session.webm")
+		if err != nil {
+			t.Fatalf("got an error but didn't want one: %v", err)
+		}
+
+		if len(tags) != 20 {
+			t.Errorf("want 20 tags, got %d: %v", len(tags), tags)
+		}
+	})
+
+	t.Run("directories and empty tags are refused", func(t *testing.T) {
+		dir, th := createTempLab(t, "Sol/oki.json")
+		defer os.RemoveAll(dir)
+
+		_, err := th.SetTags("Sol", []string{"Sol"})
+		if !errors.Is(err, ErrNotTaggable) {
+			t.Errorf("got %v, want %v", err, ErrNotTaggable)
+		}
+
+		_, err = th.SetTags("Sol/oki.json", []string{" "})
+		if !errors.Is(err, ErrEmptyTag) {
+			t.Errorf("got %v, want %v", err, ErrEmptyTag)
+		}
+	})
+}
+
+func TestFindByTags(t *testing.T) {
+	dir, th := createTempLab(t, "Sol/oki.json", "Sol/neutral.json", "Sol/setup.webm", "Ky/oki.json")
+	defer os.RemoveAll(dir)
+	setTagsHelper(t, th, "Sol/oki.json", "Sol", "oki")
+	setTagsHelper(t, th, "Sol/neutral.json", "Sol")
+	setTagsHelper(t, th, "Sol/setup.webm", "sol", "Oki", "à revoir")
+	setTagsHelper(t, th, "Ky/oki.json", "Ky", "oki")
+
+	found, err := th.FindByTags([]string{"Sol", "oki"})
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if len(found) != 2 || found[0].Path != "Sol/oki.json" || found[1].Path != "Sol/setup.webm" {
+		t.Fatalf("wrong files found: %+v", found)
+	}
+
+	if found[1].Name != "setup" || found[1].FileType != "VIDEO" {
+		t.Errorf("wrong node: %+v", found[1].Node)
+	}
+
+	counts, err := th.ListTags()
+	if err != nil {
+		t.Fatalf("got an error but didn't want one: %v", err)
+	}
+
+	if len(counts) != 4 || counts[0].Count != 3 || counts[1].Count != 3 {
+		t.Errorf("wrong tag counts: %+v", counts)
+	}
+}
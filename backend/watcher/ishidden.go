@@ -7,6 +7,11 @@ import (
 	"strings"
 )
 
+// Sur Linux et macOS, les éléments cachés sont gérés par les règles du .labignore,
+// qui ignorent par défaut les éléments commençant par un point. Les ignorer ici
+// empêcherait les négations comme !.github de s'appliquer
+const ignoreHiddenFiles = false
+
 func isHiddenFile(path string) (bool, error) {
 	return strings.HasPrefix(filepath.Base(path), "."), nil
 }
@@ -4,6 +4,9 @@ package watcher
 
 import "syscall"
 
+// Les fichiers système de Windows ne sont pas décrits par le .labignore
+const ignoreHiddenFiles = true
+
 func isHiddenFile(path string) (bool, error) {
 	pointer, err := syscall.UTF16PtrFromString(path)
 	if err != nil {
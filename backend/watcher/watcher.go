@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"flow-poc/backend/config"
+	"flow-poc/backend/filesystem/labignore"
 	"flow-poc/backend/filesystem/node"
 	"fmt"
 	"log"
@@ -134,6 +135,14 @@ type Watcher struct {
 	files        map[string]os.FileInfo // map des fichiers
 	ignored      map[string]struct{}    // map des fichiers / répertoires ignorés
 	ignoreHidden bool                   // ignore les fichiers cachés
+	generation   int                    // incrémenté à chaque changement de racine
+	interval     time.Duration          // temps entre deux sondages
+
+	// Reçoit une valeur quand une racine est surveillée, Start() l'attend si aucun lab n'est ouvert
+	rootSet chan struct{}
+
+	subMu       sync.Mutex
+	subscribers []func(Event) // fonctions appelées pour chaque évènement
 }
 
 // New crée un nouveau Watcher
@@ -142,7 +151,7 @@ func New(cfg *config.AppConfig) *Watcher {
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	return &Watcher{
+	w := &Watcher{
 		Event:   make(chan Event),
 		Error:   make(chan error),
 		Closed:  make(chan struct{}),
@@ -153,13 +162,55 @@ func New(cfg *config.AppConfig) *Watcher {
 		files:   make(map[string]os.FileInfo),
 		ignored: make(map[string]struct{}),
 		names:   make(map[string]bool),
+		rootSet: make(chan struct{}, 1),
+		// Seuls les fichiers système de Windows sont ignorés en plus du .labignore
+		ignoreHidden: ignoreHiddenFiles,
 	}
+
+	// Le watcher suit le lab ouvert et l'intervalle de sondage des paramètres
+	cfg.Subscribe(w.onConfigChange)
+
+	return w
+}
+
+func (w *Watcher) onConfigChange(c config.Change) error {
+	if c.SettingsChanged() {
+		w.mu.Lock()
+		w.interval = time.Duration(c.Settings.General.WatcherInterval) * time.Millisecond
+		w.mu.Unlock()
+	}
+
+	if !c.LabSwitched() || c.Lab == "" {
+		return nil
+	}
+
+	return w.SwitchRoot(c.Lab)
 }
 
 func (w *Watcher) SetContext(ctx context.Context) {
 	w.Ctx = ctx
 }
 
+// Subscribe enregistre une fonction appelée pour chaque évènement, avant qu'il ne soit envoyé
+// sur le channel Event. Les chemins de l'évènement sont absolus. La fonction est appelée depuis
+// la goroutine du watcher et ne doit donc pas bloquer
+func (w *Watcher) Subscribe(fn func(Event)) {
+	w.subMu.Lock()
+	defer w.subMu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+}
+
+func (w *Watcher) notify(e Event) {
+	w.subMu.Lock()
+	subscribers := w.subscribers
+	w.subMu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(e)
+	}
+}
+
 // AddRecursive ajoute un fichier ou un répertoire récursivement à la liste des fichiers
 func (w *Watcher) AddRecursive(name string) (err error) {
 	w.mu.Lock()
@@ -181,6 +232,7 @@ func (w *Watcher) AddRecursive(name string) (err error) {
 
 	// Ajout du nom dans la liste des noms
 	w.names[absPath] = true
+	w.signalRoot()
 
 	return nil
 }
@@ -188,20 +240,28 @@ func (w *Watcher) AddRecursive(name string) (err error) {
 func (w *Watcher) listRecursive(name string) (map[string]os.FileInfo, error) {
 	fileList := make(map[string]os.FileInfo)
 
+	// Le .labignore n'est relu que s'il a changé depuis le dernier sondage
+	labIgnore, err := labignore.ForLab(w.config.LabPath())
+	if err != nil {
+		return nil, err
+	}
+
 	return fileList, filepath.Walk(name, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
 		_, ignored := w.ignored[path]
+		ignored = ignored || labIgnore.Ignores(path, info.IsDir())
 
 		isHidden, err := isHiddenFile(path)
 		if err != nil {
 			return err
 		}
 
-		// Si l'élément est ignoré ou que l'on ignore les éléments cachés
-		if ignored || (w.ignoreHidden && isHidden) {
+		// Si l'élément est ignoré ou que l'on ignore les éléments cachés.
+		// La racine surveillée n'est jamais ignorée, même si elle est cachée
+		if path != name && (ignored || (w.ignoreHidden && isHidden)) {
 			// Si l'élément est un dossier
 			if info.IsDir() {
 				return filepath.SkipDir
@@ -209,12 +269,52 @@ func (w *Watcher) listRecursive(name string) (map[string]os.FileInfo, error) {
 			return nil
 		}
 
+		// Les fichiers annexes (sidecars) ne sont pas affichés dans l'arborescence
+		if !info.IsDir() && node.IsSidecar(path) {
+			return nil
+		}
+
 		// Ajout du chemin et de l'os.FileInfo du fichier dans la liste des fichiers
 		fileList[path] = info
 		return nil
 	})
 }
 
+// SwitchRoot remplace tous les éléments surveillés par name. Aucun évènement n'est émis pour
+// les fichiers de l'ancienne racine ni pour ceux de la nouvelle. Utilisé lors d'un changement de lab
+func (w *Watcher) SwitchRoot(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	absPath, err := filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+
+	fileList, err := w.listRecursive(absPath)
+	if err != nil {
+		return err
+	}
+
+	w.files = fileList
+	w.names = map[string]bool{absPath: true}
+	w.ignored = make(map[string]struct{})
+	// Le sondage en cours a été fait sur l'ancienne racine, son résultat sera ignoré
+	w.generation++
+	w.signalRoot()
+
+	return nil
+}
+
+// Réveille Start() s'il attend une racine. Appelé avec w.mu verrouillé
+func (w *Watcher) signalRoot() {
+	select {
+	case w.rootSet <- struct{}{}:
+	default:
+		// Un signal est déjà en attente
+	}
+}
+
 // RemoveRecursive supprime soit un fichier soit un répertoire de manière récursive de la liste
 // de fichiers
 func (w *Watcher) RemoveRecursive(name string) (err error) {
@@ -324,18 +424,6 @@ func (w *Watcher) Start(d time.Duration) error {
 		return ErrDurationTooShort
 	}
 
-	for {
-		// On ne veut pas démarrer le watcher tant que le chemin vers le lab
-		// n'est pas configuré
-		if w.config.ConfigFile.LabPath != "" {
-			if err := w.AddRecursive(w.config.ConfigFile.LabPath); err != nil {
-				panic(err)
-			}
-
-			break
-		}
-	}
-
 	// On vérifie si le Watcher tourne déjà
 	w.mu.Lock()
 	if w.running {
@@ -343,8 +431,28 @@ func (w *Watcher) Start(d time.Duration) error {
 		return ErrWatcherRunning
 	}
 	w.running = true
+	w.interval = d
+	hasRoot := len(w.names) != 0
 	w.mu.Unlock()
 
+	// Les changements de lab suivants sont reçus par onConfigChange
+	labPath := w.config.LabPath()
+	if !hasRoot && labPath != "" {
+		if err := w.AddRecursive(labPath); err != nil {
+			return err
+		}
+	}
+
+	// On ne veut pas démarrer le sondage tant qu'aucun lab n'est ouvert
+	for !w.hasRoot() {
+		select {
+		case <-w.close:
+			close(w.Closed)
+			return nil
+		case <-w.rootSet:
+		}
+	}
+
 	// Débloque w.Wait()
 	w.wg.Done()
 
@@ -357,6 +465,10 @@ func (w *Watcher) Start(d time.Duration) error {
 		// envoyé channel Event principal
 		evt := make(chan Event)
 
+		w.mu.Lock()
+		generation := w.generation
+		w.mu.Unlock()
+
 		// Récupère la liste des fichiers de tous les fichiers et répertoires surveillés
 		fileList := w.retrieveFileList()
 
@@ -365,7 +477,7 @@ func (w *Watcher) Start(d time.Duration) error {
 
 		// Recherche des évènements
 		go func() {
-			w.pollEvents(fileList, evt, cancel)
+			w.pollEvents(fileList, evt, cancel, generation)
 			done <- struct{}{}
 		}()
 
@@ -377,6 +489,7 @@ func (w *Watcher) Start(d time.Duration) error {
 				close(w.Closed)
 				return nil
 			case event := <-evt:
+				w.notify(event)
 				w.Event <- event
 			case <-done:
 				break inner
@@ -384,17 +497,25 @@ func (w *Watcher) Start(d time.Duration) error {
 		}
 
 		w.mu.Lock()
-		w.files = fileList
+		if w.generation == generation {
+			w.files = fileList
+		}
+		interval := w.interval
 		w.mu.Unlock()
 
-		time.Sleep(d)
+		time.Sleep(interval)
 	}
 }
 
-func (w *Watcher) pollEvents(files map[string]os.FileInfo, evt chan Event, cancel chan struct{}) {
+func (w *Watcher) pollEvents(files map[string]os.FileInfo, evt chan Event, cancel chan struct{}, generation int) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	// La racine a changé depuis la récupération des fichiers
+	if w.generation != generation {
+		return
+	}
+
 	creates := make(map[string]os.FileInfo)
 	removes := make(map[string]os.FileInfo)
 
@@ -437,7 +558,7 @@ func (w *Watcher) pollEvents(files map[string]os.FileInfo, evt chan Event, cance
 				Path:     path2,
 				OldPath:  path1,
 				FileInfo: info1,
-				FileType: node.DetectFileType(filepath.Ext(path2)),
+				FileType: node.DetectFileTypeFromPath(path2),
 				DataType: dType,
 			}
 
@@ -469,7 +590,7 @@ func (w *Watcher) pollEvents(files map[string]os.FileInfo, evt chan Event, cance
 				dType = node.DIR
 			}
 			log.Println(path)
-			e := Event{Create, path, "", "", node.DetectFileType(filepath.Ext(path)), dType, info}
+			e := Event{Create, path, "", "", node.DetectFileTypeFromPath(path), dType, info}
 			evt <- e
 		}
 	}
@@ -484,12 +605,19 @@ func (w *Watcher) pollEvents(files map[string]os.FileInfo, evt chan Event, cance
 				dType = node.DIR
 			}
 			log.Println(path)
-			e := Event{Remove, path, path, "", node.DetectFileType(filepath.Ext(path)), dType, info}
+			e := Event{Remove, path, path, "", node.DetectFileTypeFromPath(path), dType, info}
 			evt <- e
 		}
 	}
 }
 
+func (w *Watcher) hasRoot() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(w.names) != 0
+}
+
 // Bloque jusqu'à que le watcher aie démarré
 func (w *Watcher) Wait() {
 	w.wg.Wait()
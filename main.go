@@ -4,14 +4,26 @@ import (
 	"context"
 	"embed"
 	"log"
+	"os"
 	"time"
 
-	"flow-poc/backend/config"
+	"flow-poc/backend/annotation"
+	"flow-poc/backend/characters"
+	"flow-poc/backend/clip"
+	"flow-poc/backend/combos"
+	cfg "flow-poc/backend/config"
 	"flow-poc/backend/db"
+	"flow-poc/backend/filesystem/batch"
 	dirhandler "flow-poc/backend/filesystem/dir_handler"
 	"flow-poc/backend/filesystem/file_handler"
 	"flow-poc/backend/filesystem/node"
+	"flow-poc/backend/filesystem/recentfiles"
 	"flow-poc/backend/games"
+	"flow-poc/backend/jobs"
+	"flow-poc/backend/marker"
+	"flow-poc/backend/moves"
+	"flow-poc/backend/punish"
+	"flow-poc/backend/tag"
 	"flow-poc/backend/topmenu"
 	"flow-poc/backend/watcher"
 
@@ -31,20 +43,49 @@ type Bar struct {
 func main() {
 	// Create an instance of the app structure
 	app := NewApp()
-	queries := db.ConnectToDb()
 	topmenu := topmenu.NewTopMenu()
-	config := config.NewAppConfig()
-	fh := file_handler.NewFileHandler(config)
-	dh := dirhandler.NewDirHandler(config, fh.RecentFiles)
+
+	paths, err := cfg.ResolvePaths(os.Args[1:])
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// Older versions stored their files in the working directory
+	if wd, err := os.Getwd(); err == nil {
+		if err := paths.MigrateFrom(wd); err != nil {
+			log.Printf("couldn't migrate the files of the working directory: %v", err)
+		}
+	}
+
+	config := cfg.NewAppConfig(paths)
+	conn, err := db.NewConn(config)
+	if err != nil {
+		log.Printf("couldn't open the lab's database: %v", err)
+	}
+	jm := jobs.NewManager(config)
+	fh := file_handler.NewFileHandler(config, jm)
+	dh := dirhandler.NewDirHandler(config, fh.RecentFiles, fh.Favorites, jm)
+	bh := batch.NewBatchHandler(config, fh.RecentFiles, fh.Favorites)
 	w := watcher.New(config)
-	gr := games.NewGameRepository(queries)
+	fh.RecentFiles.Watch(w)
+	fh.Favorites.Watch(w)
+	gr := games.NewGameRepository(conn)
+	cr := characters.NewCharacterRepository(conn)
+	mvr := moves.NewMoveRepository(conn)
+	cbr := combos.NewComboRepository(conn)
+	pf := punish.NewPunishFinder(conn)
+	ah := annotation.NewAnnotationHandler(config)
+	mh := marker.NewMarkerHandler(config)
+	ch := clip.NewClipHandler(config)
+	th := tag.NewTagHandler(config)
 
 	go func() {
 		w.Wait()
 	}()
 
 	go func() {
-		if err := w.Start(time.Millisecond * 100); err != nil {
+		interval := time.Duration(config.GetSettings().General.WatcherInterval) * time.Millisecond
+		if err := w.Start(interval); err != nil {
 			log.Fatalln(err)
 		}
 	}()
@@ -55,7 +96,7 @@ func main() {
 			case err := <-w.Error:
 				log.Fatalln(err)
 			case evt := <-w.Event:
-				evt.MarshalFrontend(config.ConfigFile.LabPath)
+				evt.MarshalFrontend(config.LabPath())
 				log.Printf("event reçu %s", evt)
 				runtime.EventsEmit(w.Ctx, "fsop", evt)
 			}
@@ -63,7 +104,7 @@ func main() {
 	}()
 
 	// Create application with options
-	err := wails.Run(&options.App{
+	err = wails.Run(&options.App{
 		Title:            "LabMonster",
 		Width:            1024,
 		Height:           768,
@@ -79,22 +120,41 @@ func main() {
 			topmenu.SetContext(ctx)
 			config.SetContext(ctx)
 			w.SetContext(ctx)
+			go config.WatchConfigFile(ctx, time.Second)
 		},
 		Bind: []interface{}{
 			app,
 			topmenu,
 			config,
 			fh,
+			fh.Favorites,
 			dh,
+			bh,
 			gr,
+			cr,
+			mvr,
+			cbr,
+			pf,
+			ah,
+			mh,
+			ch,
+			th,
+			jm,
 		},
 		EnumBind: []interface{}{
 			watcher.FsOps,
 			node.FTypes,
 			node.DTypes,
+			node.SortModes,
+			annotation.ShapeKinds,
+			recentfiles.TimeWindows,
+			cfg.Themes,
+			cfg.RecordingQualities,
+			moves.Guards,
 		},
 		OnShutdown: func(ctx context.Context) {
 			fh.RecentFiles.SaveRecentlyOpended()
+			conn.Close()
 		},
 	})
 